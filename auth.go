@@ -0,0 +1,486 @@
+package curlx
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Authenticator 为请求添加认证信息
+// 认证在发送时作用于请求的副本，不会出现在日志和 GetRequest 中
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// ChallengeAuthenticator 可以处理401质询的认证
+// Challenge 返回true时使用新的认证信息重发一次请求
+type ChallengeAuthenticator interface {
+	Authenticator
+	Challenge(req *http.Request, resp *http.Response) bool
+}
+
+// AuthenticatorFunc 函数形式的 Authenticator
+type AuthenticatorFunc func(req *http.Request) error
+
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// credentialNamer 认证使用的请求头和URL参数名，用于日志脱敏
+type credentialNamer interface {
+	credentialNames() (headers, queryParams []string)
+}
+
+/**
+ * 设置客户端默认的认证
+ */
+func WithOptionAuth(auth Authenticator) Option {
+	return func(options *ClientOptions) {
+		options.Auth = auth
+	}
+}
+
+/**
+ * 设置请求的认证，优先于客户端的认证
+ */
+func SetParamsAuth(auth Authenticator) Param {
+	return func(param *ClientParams) {
+		param.Auth = auth
+	}
+}
+
+/**
+ * 把认证使用的请求头和URL参数加入脱敏规则
+ */
+func (r RedactRules) withCredentials(auth Authenticator) RedactRules {
+	namer, ok := auth.(credentialNamer)
+	if !ok {
+		return r
+	}
+	headers, queryParams := namer.credentialNames()
+	for _, h := range headers {
+		if !containsFold(r.Headers, h) {
+			r.Headers = append(append([]string(nil), r.Headers...), h)
+		}
+	}
+	for _, q := range queryParams {
+		if !containsFold(r.QueryParams, q) {
+			r.QueryParams = append(append([]string(nil), r.QueryParams...), q)
+		}
+	}
+	return r
+}
+
+/**
+ * Basic认证
+ */
+func BasicAuth(username, password string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+/**
+ * 固定的Bearer Token
+ */
+func BearerAuth(token string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// APIKeyLocation API Key的位置
+type APIKeyLocation int
+
+const (
+	APIKeyInHeader APIKeyLocation = iota // 请求头
+	APIKeyInQuery                        // URL参数
+)
+
+type apiKeyAuth struct {
+	name  string
+	value string
+	in    APIKeyLocation
+}
+
+/**
+ * API Key认证
+ * @param name 请求头或URL参数名
+ */
+func APIKeyAuth(name, value string, in APIKeyLocation) Authenticator {
+	return &apiKeyAuth{name: name, value: value, in: in}
+}
+
+func (a *apiKeyAuth) Authenticate(req *http.Request) error {
+	if a.in == APIKeyInQuery {
+		q := req.URL.Query()
+		q.Set(a.name, a.value)
+		req.URL.RawQuery = q.Encode()
+		return nil
+	}
+	req.Header.Set(a.name, a.value)
+	return nil
+}
+
+func (a *apiKeyAuth) credentialNames() ([]string, []string) {
+	if a.in == APIKeyInQuery {
+		return nil, []string{a.name}
+	}
+	return []string{a.name}, nil
+}
+
+// authTransport 发送前添加认证和签名，收到401质询时重发一次
+// 签名在认证之后，认证添加的请求头和URL参数参与签名
+type authTransport struct {
+	next        http.RoundTripper
+	auth        Authenticator
+	signer      Signer
+	payloadHash string // 请求Body的摘要，包装Body前计算
+	host        string // 只对原始请求的主机认证，避免重定向泄露
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.next.RoundTrip(req)
+	}
+	authed, err := t.authorize(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(authed)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenger, ok := t.auth.(ChallengeAuthenticator)
+	if !ok || !challenger.Challenge(req, resp) {
+		return resp, nil
+	}
+	// 无法重新读取Body时不重发
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if hasBody && req.GetBody == nil {
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	if hasBody {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	if authed, err = t.authorize(retry); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(authed)
+}
+
+func (t *authTransport) authorize(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	before := r.Header.Clone()
+	if t.auth != nil {
+		if err := t.auth.Authenticate(r); err != nil {
+			return nil, fmt.Errorf("curlx: authenticate: %w", err)
+		}
+	}
+	if t.signer == nil {
+		return r, nil
+	}
+
+	authed := r.Header.Clone()
+	if err := signRequest(t.signer, r, t.payloadHash); err != nil {
+		return nil, err
+	}
+	// 签名覆盖了认证的请求头时，服务端收到的认证与预期不符
+	for k, v := range authed {
+		if strings.Join(v, ",") != strings.Join(before[k], ",") && strings.Join(r.Header[k], ",") != strings.Join(v, ",") {
+			return nil, fmt.Errorf("%w: %s", ErrSignConflict, k)
+		}
+	}
+	return r, nil
+}
+
+// ErrDigestChallenge 401响应中没有可用的Digest质询
+var ErrDigestChallenge = errors.New("curlx: no supported digest challenge")
+
+// digestChallenge WWW-Authenticate: Digest 的参数
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	userhash  bool
+}
+
+// DigestAuthenticator HTTP Digest认证 RFC 7616
+// 收到质询后记录nonce，之后的请求直接携带认证信息
+type DigestAuthenticator struct {
+	username string
+	password string
+
+	mu        sync.Mutex
+	challenge *digestChallenge
+	nc        int
+	cnonce    func() string
+}
+
+/**
+ * Digest认证，支持 MD5/SHA-256/SHA-512-256 及 -sess，qop为auth或auth-int
+ */
+func DigestAuth(username, password string) *DigestAuthenticator {
+	return &DigestAuthenticator{username: username, password: password, cnonce: newCnonce}
+}
+
+func newCnonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+/**
+ * 还没有收到质询时不添加认证信息
+ */
+func (d *DigestAuthenticator) Authenticate(req *http.Request) error {
+	d.mu.Lock()
+	c := d.challenge
+	if c == nil {
+		d.mu.Unlock()
+		return nil
+	}
+	d.nc++
+	nc := d.nc
+	cnonce := d.cnonce()
+	d.mu.Unlock()
+
+	header, err := d.authorization(c, req, nc, cnonce)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", header)
+	return nil
+}
+
+/**
+ * 解析质询，nonce变化或首次质询时重发
+ */
+func (d *DigestAuthenticator) Challenge(req *http.Request, resp *http.Response) bool {
+	var best *digestChallenge
+	for _, v := range resp.Header.Values("WWW-Authenticate") {
+		c, ok := parseDigestChallenge(v)
+		if !ok || digestHash(c.algorithm) == nil {
+			continue
+		}
+		if best == nil || digestStrength(c.algorithm) > digestStrength(best.algorithm) {
+			best = c
+		}
+	}
+	if best == nil {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// 已携带同一个nonce的认证仍然失败，说明用户名或密码错误
+	sent := resp.Request != nil && resp.Request.Header.Get("Authorization") != ""
+	if sent && d.challenge != nil && d.challenge.nonce == best.nonce {
+		return false
+	}
+	d.challenge = best
+	d.nc = 0
+	return true
+}
+
+func (d *DigestAuthenticator) authorization(c *digestChallenge, req *http.Request, nc int, cnonce string) (string, error) {
+	newHash := digestHash(c.algorithm)
+	if newHash == nil {
+		return "", ErrDigestChallenge
+	}
+	h := func(s string) string {
+		hh := newHash()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	uri := req.URL.RequestURI()
+	ncValue := fmt.Sprintf("%08x", nc)
+
+	ha1 := h(d.username + ":" + c.realm + ":" + d.password)
+	if strings.HasSuffix(strings.ToUpper(c.algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+
+	qop := selectQop(c.qop)
+	ha2 := h(req.Method + ":" + uri)
+	if qop == "auth-int" {
+		body, err := requestBody(req)
+		if err != nil {
+			return "", err
+		}
+		ha2 = h(req.Method + ":" + uri + ":" + h(string(body)))
+	}
+
+	var response string
+	if qop == "" {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + c.nonce + ":" + ncValue + ":" + cnonce + ":" + qop + ":" + ha2)
+	}
+
+	username := d.username
+	if c.userhash {
+		username = h(d.username + ":" + c.realm)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, username),
+		fmt.Sprintf(`realm="%s"`, c.realm),
+		fmt.Sprintf(`uri="%s"`, uri),
+	}
+	if c.algorithm != "" {
+		parts = append(parts, "algorithm="+c.algorithm)
+	}
+	parts = append(parts, fmt.Sprintf(`nonce="%s"`, c.nonce))
+	if qop != "" {
+		parts = append(parts, "nc="+ncValue, fmt.Sprintf(`cnonce="%s"`, cnonce), "qop="+qop)
+	}
+	parts = append(parts, fmt.Sprintf(`response="%s"`, response))
+	if c.opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, c.opaque))
+	}
+	if c.userhash {
+		parts = append(parts, "userhash=true")
+	}
+	return "Digest " + strings.Join(parts, ", "), nil
+}
+
+/**
+ * 读取请求Body用于 auth-int，不影响后续发送
+ */
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("curlx: digest auth-int requires a replayable body")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+/**
+ * 优先使用auth
+ */
+func selectQop(qop string) string {
+	if qop == "" {
+		return ""
+	}
+	options := strings.Split(qop, ",")
+	for _, o := range options {
+		if strings.TrimSpace(o) == "auth" {
+			return "auth"
+		}
+	}
+	for _, o := range options {
+		if strings.TrimSpace(o) == "auth-int" {
+			return "auth-int"
+		}
+	}
+	return ""
+}
+
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "", "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	case "SHA-512-256":
+		return sha512.New512_256
+	}
+	return nil
+}
+
+func digestStrength(algorithm string) int {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "SHA-512-256":
+		return 3
+	case "SHA-256":
+		return 2
+	}
+	return 1
+}
+
+/**
+ * 解析 Digest 质询，参数值可以带引号
+ */
+func parseDigestChallenge(v string) (*digestChallenge, bool) {
+	v = strings.TrimSpace(v)
+	if len(v) < 7 || !strings.EqualFold(v[:7], "Digest ") {
+		return nil, false
+	}
+	params := parseAuthParams(v[7:])
+	c := &digestChallenge{
+		realm:     params["realm"],
+		nonce:     params["nonce"],
+		opaque:    params["opaque"],
+		algorithm: params["algorithm"],
+		qop:       params["qop"],
+		userhash:  strings.EqualFold(params["userhash"], "true"),
+	}
+	return c, c.nonce != ""
+}
+
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ,")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " ")
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			value = b.String()
+			if i < len(s) {
+				i++
+			}
+			s = s[i:]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = value
+	}
+	return params
+}
@@ -0,0 +1,176 @@
+package curlx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStaticAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("X-Api-Key") + "|" + r.URL.Query().Get("key")))
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		name string
+		opts []Option
+		ps   []Param
+		want string
+	}{
+		{"basic", []Option{WithOptionAuth(BasicAuth("bob", "pw"))}, nil, "Basic Ym9iOnB3||"},
+		{"bearer", nil, []Param{SetParamsAuth(BearerAuth("tok"))}, "Bearer tok||"},
+		{"header", nil, []Param{SetParamsAuth(APIKeyAuth("X-Api-Key", "k1", APIKeyInHeader))}, "|k1|"},
+		{"query", nil, []Param{SetParamsAuth(APIKeyAuth("key", "k2", APIKeyInQuery))}, "||k2"},
+		// 请求的认证优先
+		{"override", []Option{WithOptionAuth(BasicAuth("bob", "pw"))}, []Param{SetParamsAuth(BearerAuth("tok"))}, "Bearer tok||"},
+	}
+	for _, c := range cases {
+		ps := append([]Param{SetParamsUrl(srv.URL), SetParamsMethod(MethodGet)}, c.ps...)
+		resp := NewCurlx(c.opts...).SendWithResponse(context.Background(), ps...)
+		body, err := resp.GetBody()
+		if err != nil || string(body) != c.want {
+			t.Errorf("%s: body = %q, err = %v, want %q", c.name, body, err, c.want)
+		}
+		if resp.GetRequest().Header.Get("Authorization") != "" {
+			t.Errorf("%s: original request should not be modified", c.name)
+		}
+	}
+}
+
+func TestAuthNotSentToOtherHost(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer other.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL, http.StatusFound)
+	}))
+	defer srv.Close()
+
+	body, err := NewCurlx(WithOptionAuth(BearerAuth("tok"))).Send(context.Background(),
+		SetParamsUrl(srv.URL), SetParamsMethod(MethodGet))
+	if err != nil || string(body) != "" {
+		t.Errorf("body = %q, err = %v", body, err)
+	}
+}
+
+func TestAuthRedactedInLogs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	logger := &captureLogger{}
+	c := NewCurlx(WithOptionStructuredLogger(logger), WithOptionLogLevel(LevelDebug),
+		WithOptionAuth(APIKeyAuth("X-Tenant-Key", "tenant-secret", APIKeyInHeader)))
+	_, err := c.Send(context.Background(),
+		SetParamsUrl(srv.URL+"/?sig=query-secret"),
+		SetParamsMethod(MethodGet),
+		SetParamsHeader("X-Tenant-Key", "tenant-secret"),
+		SetParamsAuth(APIKeyAuth("sig", "query-secret", APIKeyInQuery)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := logger.String()
+	for _, secret := range []string{"tenant-secret", "query-secret"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("logs contain %q:\n%s", secret, logs)
+		}
+	}
+}
+
+// RFC 7616 3.9.1 示例
+func TestDigestVectors(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://www.example.org/dir/index.html", nil)
+	d := DigestAuth("Mufasa", "Circle of Life")
+	cnonce := "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+
+	cases := map[string]string{
+		"MD5":     "8ca523f5e9506fed4657c9700eebdbec",
+		"SHA-256": "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+	}
+	for algorithm, want := range cases {
+		c := &digestChallenge{
+			realm:     "http-auth@example.org",
+			nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+			opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+			algorithm: algorithm,
+			qop:       "auth, auth-int",
+		}
+		header, err := d.authorization(c, req, 1, cnonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		params := parseAuthParams(strings.TrimPrefix(header, "Digest "))
+		if params["response"] != want {
+			t.Errorf("%s response = %s, want %s", algorithm, params["response"], want)
+		}
+		if params["nc"] != "00000001" || params["qop"] != "auth" || params["uri"] != "/dir/index.html" {
+			t.Errorf("%s header = %s", algorithm, header)
+		}
+	}
+}
+
+func TestDigestRoundTrip(t *testing.T) {
+	const (
+		realm = "api@example.org"
+		nonce = "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+	)
+	h := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	var challenges int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if strings.HasPrefix(auth, "Digest ") {
+			p := parseAuthParams(strings.TrimPrefix(auth, "Digest "))
+			ha1 := h("alice:" + realm + ":secret")
+			ha2 := h(r.Method + ":" + p["uri"])
+			want := h(ha1 + ":" + nonce + ":" + p["nc"] + ":" + p["cnonce"] + ":" + p["qop"] + ":" + ha2)
+			if p["response"] == want && p["opaque"] == "xyz" {
+				w.Write([]byte("welcome"))
+				return
+			}
+		}
+		atomic.AddInt32(&challenges, 1)
+		w.Header().Add("WWW-Authenticate", `Digest realm="`+realm+`", qop="auth", algorithm=MD5, nonce="`+nonce+`", opaque="xyz"`)
+		w.Header().Add("WWW-Authenticate", `Digest realm="`+realm+`", qop="auth", algorithm=SHA-256, nonce="`+nonce+`", opaque="xyz"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := NewCurlx(WithOptionAuth(DigestAuth("alice", "secret")))
+	for i := 0; i < 2; i++ {
+		body, err := c.Send(context.Background(),
+			SetParamsUrl(srv.URL+"/resource?id="+url.QueryEscape("a b")),
+			SetParamsMethod(MethodPost),
+			SetParamsBody([]byte("payload")),
+			SetParamsContentType(ContentTypeText),
+		)
+		if err != nil || string(body) != "welcome" {
+			t.Fatalf("attempt %d: body = %q, err = %v", i, body, err)
+		}
+	}
+	// 第二次请求直接携带认证
+	if challenges != 1 {
+		t.Errorf("challenges = %d, want 1", challenges)
+	}
+
+	// 密码错误时只重发一次
+	atomic.StoreInt32(&challenges, 0)
+	resp := NewCurlx().SendWithResponse(context.Background(),
+		SetParamsUrl(srv.URL), SetParamsMethod(MethodGet), SetParamsAuth(DigestAuth("alice", "wrong")))
+	defer resp.Close()
+	if resp.GetStatusCode() != http.StatusUnauthorized || challenges != 2 {
+		t.Errorf("status = %d, challenges = %d", resp.GetStatusCode(), challenges)
+	}
+}
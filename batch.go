@@ -0,0 +1,142 @@
+package curlx
+
+import (
+	"context"
+	"sync"
+)
+
+// RequestSpec 批量请求中的单个请求
+type RequestSpec struct {
+	Params []Param
+}
+
+// BatchResult 批量请求中单个请求的结果
+type BatchResult struct {
+	Index    int      // 在请求列表中的下标
+	Response Response // 响应(Body已读取到内存)
+	Err      error
+}
+
+type batchOptions struct {
+	failFast bool
+	progress func(done, total int, result BatchResult)
+}
+
+type BatchOption func(*batchOptions)
+
+/**
+ * 任意请求失败后取消剩余请求
+ */
+func WithBatchFailFast() BatchOption {
+	return func(o *batchOptions) {
+		o.failFast = true
+	}
+}
+
+/**
+ * 设置进度回调，每完成一个请求回调一次
+ * 回调可能在多个goroutine中执行，但不会并发调用
+ */
+func WithBatchProgress(fn func(done, total int, result BatchResult)) BatchOption {
+	return func(o *batchOptions) {
+		o.progress = fn
+	}
+}
+
+/**
+ * 并发批量请求
+ * @param concurrency 最大并发数，<=0 时不限制
+ * 返回结果与specs顺序一致，父级ctx结束后剩余请求会被取消
+ */
+func (c *Curlx) SendBatch(ctx context.Context, specs []RequestSpec, concurrency int, opts ...BatchOption) []BatchResult {
+	bo := batchOptions{}
+	for _, apply := range opts {
+		apply(&bo)
+	}
+
+	results := make([]BatchResult, len(specs))
+	if len(specs) == 0 {
+		return results
+	}
+
+	if concurrency <= 0 || concurrency > len(specs) {
+		concurrency = len(specs)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+
+	finish := func(result BatchResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[result.Index] = result
+		done++
+		if result.Err != nil && bo.failFast {
+			cancel()
+		}
+		if bo.progress != nil {
+			bo.progress(done, len(specs), result)
+		}
+	}
+
+	jobs := make(chan int)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				finish(c.sendBatchOne(ctx, index, specs[index]))
+			}
+		}()
+	}
+
+	for index := range specs {
+		select {
+		case <-ctx.Done():
+			// 未开始的请求直接返回取消原因
+			finish(BatchResult{
+				Index:    index,
+				Response: Response{err: ctx.Err()},
+				Err:      ctx.Err(),
+			})
+			continue
+		case jobs <- index:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func (c *Curlx) sendBatchOne(ctx context.Context, index int, spec RequestSpec) BatchResult {
+	result := BatchResult{Index: index}
+
+	if err := ctx.Err(); err != nil {
+		result.Response = Response{err: err}
+		result.Err = err
+		return result
+	}
+
+	resp := c.exec(ctx, spec.Params...)
+	if resp.err != nil {
+		result.Response = resp
+		result.Err = resp.err
+		return result
+	}
+
+	// 在ctx取消前读取Body，避免其他请求失败后影响已完成的响应
+	if _, err := resp.GetBody(); err != nil {
+		resp.Close()
+		resp.err = err
+	}
+	result.Response = resp
+	result.Err = resp.err
+	return result
+}
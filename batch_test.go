@@ -0,0 +1,87 @@
+package curlx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSendBatch(t *testing.T) {
+	var active, peak int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(r.URL.Query().Get("i")))
+	}))
+	defer srv.Close()
+
+	specs := []RequestSpec{}
+	for i := 0; i < 10; i++ {
+		specs = append(specs, RequestSpec{Params: []Param{
+			SetParamsUrl(fmt.Sprintf("%s/?i=%d", srv.URL, i)),
+			SetParamsMethod(MethodGet),
+		}})
+	}
+
+	progress := 0
+	results := NewCurlx().SendBatch(context.Background(), specs, 3,
+		WithBatchProgress(func(done, total int, result BatchResult) {
+			progress = done
+		}),
+	)
+
+	if progress != len(specs) {
+		t.Fatalf("progress = %d, want %d", progress, len(specs))
+	}
+	if peak > 3 {
+		t.Fatalf("peak concurrency = %d, want <= 3", peak)
+	}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("result %d err: %v", i, result.Err)
+		}
+		body, _ := result.Response.GetBody()
+		if string(body) != fmt.Sprint(i) {
+			t.Fatalf("result %d body = %q", i, body)
+		}
+	}
+}
+
+func TestSendBatchFailFast(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	specs := []RequestSpec{
+		// 无效的请求方法，直接失败
+		{Params: []Param{SetParamsUrl(srv.URL)}},
+	}
+	for i := 0; i < 5; i++ {
+		specs = append(specs, RequestSpec{Params: []Param{
+			SetParamsUrl(srv.URL),
+			SetParamsMethod(MethodGet),
+		}})
+	}
+
+	results := NewCurlx().SendBatch(context.Background(), specs, 1, WithBatchFailFast())
+	if results[0].Err == nil {
+		t.Fatal("expected first request to fail")
+	}
+	for i, result := range results[1:] {
+		if result.Err != context.Canceled {
+			t.Fatalf("result %d err = %v, want context.Canceled", i+1, result.Err)
+		}
+	}
+}
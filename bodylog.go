@@ -0,0 +1,224 @@
+package curlx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// BodyLogFormat 日志中Body的输出格式
+type BodyLogFormat int
+
+const (
+	BodyLogRaw         BodyLogFormat = iota // 原样输出
+	BodyLogCompactJSON                      // JSON压缩为一行
+	BodyLogPrettyJSON                       // JSON缩进格式化
+)
+
+// 超过该大小的JSON不做解析(脱敏/格式化)，只输出大小
+const maxBodyLogParse = 1 << 20
+
+// 用于判断是否为二进制内容的前缀长度
+const bodySniffLen = 512
+
+/**
+ * 设置请求Body日志输出长度(字符数)，默认使用 LoggerLength
+ */
+func WithOptionRequestBodyLogLength(length int) Option {
+	return func(options *ClientOptions) {
+		options.RequestBodyLogLength = length
+	}
+}
+
+/**
+ * 设置响应Body日志输出长度(字符数)，默认使用 LoggerLength
+ */
+func WithOptionResponseBodyLogLength(length int) Option {
+	return func(options *ClientOptions) {
+		options.ResponseBodyLogLength = length
+	}
+}
+
+/**
+ * 设置日志中JSON Body的输出格式
+ */
+func WithOptionBodyLogFormat(format BodyLogFormat) Option {
+	return func(options *ClientOptions) {
+		options.BodyLogFormat = format
+	}
+}
+
+func (o ClientOptions) requestBodyLogLength() int {
+	if o.RequestBodyLogLength > 0 {
+		return o.RequestBodyLogLength
+	}
+	return o.LoggerLength
+}
+
+func (o ClientOptions) responseBodyLogLength() int {
+	if o.ResponseBodyLogLength > 0 {
+		return o.ResponseBodyLogLength
+	}
+	return o.LoggerLength
+}
+
+/**
+ * 格式化日志中的Body
+ * 只处理前limit个字符，二进制内容只输出类型和大小
+ */
+func (o ClientOptions) formatBodyLog(body []byte, contentType string, limit int) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	mediaType = strings.ToLower(mediaType)
+
+	if isBinaryBody(mediaType, body) {
+		if mediaType == "" {
+			mediaType = http.DetectContentType(sniffPrefix(body))
+		}
+		return fmt.Sprintf("[binary body: %s, %d bytes]", mediaType, len(body))
+	}
+
+	if isJSONMediaType(mediaType) || (mediaType == "" && looksLikeJSON(body)) {
+		if len(body) > maxBodyLogParse {
+			return fmt.Sprintf("[json body: %d bytes]", len(body))
+		}
+		body = o.Redact.RedactJSON(body)
+		body = formatJSON(body, o.BodyLogFormat)
+	} else if mediaType == string(ContentTypeUrlEncoded) {
+		if len(body) > maxBodyLogParse {
+			return fmt.Sprintf("[form body: %d bytes]", len(body))
+		}
+		body = o.Redact.redactForm(body)
+	}
+
+	return truncateRunes(body, limit)
+}
+
+/**
+ * 格式化请求参数中的表单，文件只输出文件名和大小
+ */
+func (o ClientOptions) formatFormLog(body []byte, limit int) string {
+	if len(body) > maxBodyLogParse*8 {
+		return fmt.Sprintf("[multipart form: %d bytes]", len(body))
+	}
+	params := []FormParam{}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return fmt.Sprintf("[multipart form: %d bytes]", len(body))
+	}
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		if p.FieldType == FieldTypeFile {
+			parts = append(parts, fmt.Sprintf("%s=@%s(%d bytes)", p.FieldName, p.FileName, len(p.FileBytes)))
+			continue
+		}
+		value := p.FieldValue
+		if containsFold(o.Redact.JSONFields, p.FieldName) {
+			value = o.Redact.mask()
+		}
+		parts = append(parts, p.FieldName+"="+value)
+	}
+	return truncateRunes([]byte("[multipart form: "+strings.Join(parts, ", ")+"]"), limit)
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") || isJSONMediaType(mediaType) {
+		return true
+	}
+	switch mediaType {
+	case "application/xml", "application/javascript", "application/x-www-form-urlencoded",
+		"application/x-ndjson", "application/graphql":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+xml")
+}
+
+/**
+ * 判断是否为二进制内容，未知类型时根据前缀内容判断
+ */
+func isBinaryBody(mediaType string, body []byte) bool {
+	if isTextMediaType(mediaType) {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "font/"),
+		mediaType == "application/octet-stream",
+		mediaType == "application/pdf",
+		mediaType == "application/zip",
+		mediaType == "application/gzip",
+		mediaType == "application/x-protobuf",
+		mediaType == "application/protobuf":
+		return true
+	}
+
+	sniff := sniffPrefix(body)
+	return bytes.IndexByte(sniff, 0) >= 0 || !utf8.Valid(sniff)
+}
+
+/**
+ * 取Body前缀用于判断内容类型，去掉被截断的末尾字符
+ */
+func sniffPrefix(body []byte) []byte {
+	if len(body) <= bodySniffLen {
+		return body
+	}
+	sniff := body[:bodySniffLen]
+	for i := 0; i < utf8.UTFMax-1 && !utf8.Valid(sniff); i++ {
+		sniff = sniff[:len(sniff)-1]
+	}
+	return sniff
+}
+
+func looksLikeJSON(body []byte) bool {
+	trimmed := bytes.TrimLeft(sniffPrefix(body), " \t\r\n")
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+func formatJSON(body []byte, format BodyLogFormat) []byte {
+	buf := &bytes.Buffer{}
+	var err error
+	switch format {
+	case BodyLogCompactJSON:
+		err = json.Compact(buf, body)
+	case BodyLogPrettyJSON:
+		err = json.Indent(buf, body, "", "  ")
+	default:
+		return body
+	}
+	if err != nil {
+		return body
+	}
+	return buf.Bytes()
+}
+
+/**
+ * 截取前limit个字符，不会截断多字节字符，只遍历前缀
+ */
+func truncateRunes(body []byte, limit int) string {
+	if limit <= 0 {
+		return fmt.Sprintf("[%d bytes]", len(body))
+	}
+	n, i := 0, 0
+	for i < len(body) && n < limit {
+		_, size := utf8.DecodeRune(body[i:])
+		i += size
+		n++
+	}
+	if i >= len(body) {
+		return string(body)
+	}
+	return fmt.Sprintf("%s...(%d bytes)", body[:i], len(body))
+}
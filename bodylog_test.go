@@ -0,0 +1,58 @@
+package curlx
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFormatBodyLog(t *testing.T) {
+	opts := defaultOptions()
+
+	cases := []struct {
+		name        string
+		body        []byte
+		contentType string
+		limit       int
+		format      BodyLogFormat
+		want        string
+	}{
+		{"short text", []byte("hello"), "text/plain", 10, BodyLogRaw, "hello"},
+		{"rune safe", []byte("你好世界"), "text/plain", 2, BodyLogRaw, "你好...(12 bytes)"},
+		{"binary type", []byte("\x89PNG...."), "image/png", 10, BodyLogRaw, "[binary body: image/png, 8 bytes]"},
+		{"binary sniff", []byte{0x1f, 0x8b, 0x08, 0x00}, "", 10, BodyLogRaw, "[binary body: application/x-gzip, 4 bytes]"},
+		{"json redact", []byte(`{"password":"x"}`), "application/json", 100, BodyLogRaw, `{"password":"******"}`},
+		{"json compact", []byte("{\n  \"a\": 1\n}"), "application/json; charset=utf-8", 100, BodyLogCompactJSON, `{"a":1}`},
+		{"json pretty", []byte(`{"a":1}`), "", 100, BodyLogPrettyJSON, "{\n  \"a\": 1\n}"},
+		{"form redact", []byte("client_id=app&client_secret=x"), "application/x-www-form-urlencoded", 100, BodyLogRaw, "client_id=app&client_secret=%2A%2A%2A%2A%2A%2A"},
+		{"form params redact", []byte(`{"refresh_token":"x"}`), "application/x-www-form-urlencoded", 100, BodyLogRaw, `{"refresh_token":"******"}`},
+		{"zero limit", []byte("hello"), "text/plain", 0, BodyLogRaw, "[5 bytes]"},
+	}
+	for _, c := range cases {
+		opts.BodyLogFormat = c.format
+		if got := opts.formatBodyLog(c.body, c.contentType, c.limit); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestFormatFormLog(t *testing.T) {
+	body, _ := json.Marshal([]FormParam{
+		{FieldName: "name", FieldValue: "bob", FieldType: FieldTypeText},
+		{FieldName: "password", FieldValue: "x", FieldType: FieldTypeText},
+		{FieldName: "file", FileName: "a.bin", FileBytes: make([]byte, 2048), FieldType: FieldTypeFile},
+	})
+	got := defaultOptions().formatFormLog(body, 200)
+	want := "[multipart form: name=bob, password=******, file=@a.bin(2048 bytes)]"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatBodyLogLargeBody(t *testing.T) {
+	body := []byte(strings.Repeat("a", 10<<20))
+	got := defaultOptions().formatBodyLog(body, "text/plain", 3)
+	if got != "aaa...(10485760 bytes)" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,164 @@
+package curlx
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestBuilder 链式构建请求
+// 每个方法添加对应的 Param，最终与 Send/SendWithResponse 一样经过 exec 发送
+//
+//	resp := c.R().Method(MethodPost).URL("/users/{id}").PathParam("id", "1").JSON(user).Do(ctx)
+type RequestBuilder struct {
+	c       *Curlx
+	params  []Param
+	timeout time.Duration
+}
+
+/**
+ * 创建请求构建器
+ */
+func (c *Curlx) R() *RequestBuilder {
+	return &RequestBuilder{c: c}
+}
+
+/**
+ * 添加任意参数，用于构建器没有提供的设置
+ */
+func (b *RequestBuilder) Param(ps ...Param) *RequestBuilder {
+	b.params = append(b.params, ps...)
+	return b
+}
+
+func (b *RequestBuilder) Method(m Method) *RequestBuilder {
+	return b.Param(SetParamsMethod(m))
+}
+
+func (b *RequestBuilder) URL(url string) *RequestBuilder {
+	return b.Param(SetParamsUrl(url))
+}
+
+/**
+ * 设置路径参数，替换URL中的 {name}
+ */
+func (b *RequestBuilder) PathParam(name, value string) *RequestBuilder {
+	return b.Param(SetParamsPathParam(name, value))
+}
+
+/**
+ * 添加请求头，同名请求头保留所有值
+ */
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	return b.Param(SetParamsHeader(key, value))
+}
+
+/**
+ * 添加查询参数
+ */
+func (b *RequestBuilder) Query(key string, values ...string) *RequestBuilder {
+	return b.Param(SetParamsQuery(key, values...))
+}
+
+/**
+ * 按结构体的 url 标签添加查询参数
+ */
+func (b *RequestBuilder) QueryStruct(v any) *RequestBuilder {
+	return b.Param(SetParamsQueryStruct(v))
+}
+
+func (b *RequestBuilder) Cookie(name, value string) *RequestBuilder {
+	return b.Param(SetCookie(name, value))
+}
+
+/**
+ * 设置原始Body和数据类型
+ */
+func (b *RequestBuilder) Body(body []byte, t ContentType) *RequestBuilder {
+	return b.Param(SetParamsBody(body), SetParamsContentType(t))
+}
+
+/**
+ * 以JSON发送，v为[]byte或string时原样发送
+ */
+func (b *RequestBuilder) JSON(v any) *RequestBuilder {
+	return b.Param(SetParamsBodyAny(v), SetParamsContentType(ContentTypeJson))
+}
+
+/**
+ * 添加表单文本字段(multipart/form-data)
+ */
+func (b *RequestBuilder) Form(fieldName, fieldValue string) *RequestBuilder {
+	return b.Param(SetParamsFormText(fieldName, fieldValue), SetParamsContentType(ContentTypeForm))
+}
+
+/**
+ * 添加表单文件(multipart/form-data)
+ */
+func (b *RequestBuilder) File(fieldName, fileName string, fileBytes []byte) *RequestBuilder {
+	return b.Param(SetParamsFormFile(fieldName, fileName, fileBytes), SetParamsContentType(ContentTypeForm))
+}
+
+/**
+ * 设置整个请求的超时时间，包括重试
+ */
+func (b *RequestBuilder) Timeout(d time.Duration) *RequestBuilder {
+	b.timeout = d
+	return b
+}
+
+/**
+ * 设置重试次数和间隔
+ */
+func (b *RequestBuilder) Retry(count int, delay time.Duration) *RequestBuilder {
+	return b.Param(SetParamsRetry(count, delay))
+}
+
+func (b *RequestBuilder) RetryPolicy(policy RetryPolicy) *RequestBuilder {
+	return b.Param(SetParamsRetryPolicy(policy))
+}
+
+func (b *RequestBuilder) Auth(auth Authenticator) *RequestBuilder {
+	return b.Param(SetParamsAuth(auth))
+}
+
+/**
+ * 生成的请求参数，已合并客户端的默认值
+ */
+func (b *RequestBuilder) Params() ClientParams {
+	return b.c.newParams(b.params...)
+}
+
+/**
+ * 发送请求
+ * 注意：外部使用需要调用 Close 或 GetBody 释放连接
+ */
+func (b *RequestBuilder) Do(ctx context.Context) Response {
+	if b.timeout <= 0 {
+		return b.c.SendWithResponse(ctx, b.params...)
+	}
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	resp := b.c.SendWithResponse(ctx, b.params...)
+	if resp.response == nil || resp.response.Body == nil || resp.response.Body == http.NoBody {
+		cancel()
+		return resp
+	}
+	// 读取完或关闭Body后再取消，避免中断Body读取
+	resp.response.Body = &hookBody{
+		ReadCloser: resp.response.Body,
+		onDone:     func(int64) { cancel() },
+	}
+	return resp
+}
+
+/**
+ * 发送请求并返回Body，与 Send 相同，状态码不是200时返回 ErrStatusNotOK
+ */
+func (b *RequestBuilder) Send(ctx context.Context) ([]byte, error) {
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+	return b.c.Send(ctx, b.params...)
+}
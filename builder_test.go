@@ -0,0 +1,64 @@
+package curlx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Method + " " + r.URL.RequestURI() + " " + r.Header.Get("X-Trace") + " " + r.Header.Get("Content-Type") + " " + string(body)))
+	}))
+	defer srv.Close()
+
+	c := NewCurlx(WithOptionBaseURL(srv.URL))
+	resp := c.R().
+		Method(MethodPost).
+		URL("/users/{id}").
+		PathParam("id", "42").
+		Query("fields", "name", "email").
+		Header("X-Trace", "abc").
+		JSON(map[string]string{"name": "bob"}).
+		Timeout(5 * time.Second).
+		Do(context.Background())
+	body, err := resp.GetBody()
+	want := `POST /users/42?fields=name&fields=email abc application/json {"name":"bob"}`
+	if err != nil || string(body) != want {
+		t.Errorf("body = %q, err = %v", body, err)
+	}
+
+	// 与 Param 列表生成相同的参数
+	built := c.R().Method(MethodGet).URL("/a").Header("X-A", "1").Query("q", "1").Retry(2, time.Millisecond).Params()
+	plain := c.newParams(SetParamsMethod(MethodGet), SetParamsUrl("/a"), SetParamsHeader("X-A", "1"),
+		SetParamsQuery("q", "1"), SetParamsRetry(2, time.Millisecond))
+	if !reflect.DeepEqual(built, plain) {
+		t.Errorf("built = %+v\nplain = %+v", built, plain)
+	}
+
+	form := c.R().Method(MethodPost).URL("/upload").Form("name", "bob").File("avatar", "a.png", []byte("png")).Params()
+	if form.ContentType != ContentTypeForm || len(form.Body) == 0 {
+		t.Errorf("form params = %+v", form)
+	}
+}
+
+func TestRequestBuilderTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	_, err := NewCurlx().R().Method(MethodGet).URL(srv.URL).Timeout(50 * time.Millisecond).Send(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,570 @@
+package curlx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheStatus 响应的缓存状态
+type CacheStatus int
+
+const (
+	CacheNone        CacheStatus = iota // 未启用缓存或请求不可缓存
+	CacheMiss                           // 从服务端获取
+	CacheHit                            // 直接使用缓存
+	CacheRevalidated                    // 服务端返回304，使用缓存
+	CacheStale                          // 使用过期缓存(stale-while-revalidate/max-stale)
+)
+
+func (s CacheStatus) String() string {
+	switch s {
+	case CacheMiss:
+		return "miss"
+	case CacheHit:
+		return "hit"
+	case CacheRevalidated:
+		return "revalidated"
+	case CacheStale:
+		return "stale"
+	}
+	return "none"
+}
+
+// 超过该大小的响应不缓存
+const maxCacheBodySize = 32 << 20
+
+// 后台重新验证的超时时间
+const cacheRevalidateTimeout = time.Minute
+
+/**
+ * 启用响应缓存，遵循 RFC 9111 私有缓存语义
+ * 只缓存GET请求，缓存命中时不经过中间件
+ * 缓存由客户端的所有调用方共享，携带凭证的请求按共享缓存处理(RFC 9111 3.5)，
+ * 响应没有 public、s-maxage 或 must-revalidate 时不保存
+ * 凭证包括认证、Cookie，以及脱敏规则中的请求头和URL参数(如 X-Api-Key、api_key)
+ */
+func WithOptionCache(storage CacheStorage) Option {
+	return func(options *ClientOptions) {
+		options.Cache = storage
+	}
+}
+
+type cacheStatusKey struct{}
+
+func withCacheStatus(ctx context.Context, status *CacheStatus) context.Context {
+	return context.WithValue(ctx, cacheStatusKey{}, status)
+}
+
+func setCacheStatus(ctx context.Context, status CacheStatus) {
+	if p, ok := ctx.Value(cacheStatusKey{}).(*CacheStatus); ok {
+		*p = status
+	}
+}
+
+type cacheCredentialsKey struct{}
+
+/**
+ * 请求的脱敏规则，其中的请求头和URL参数视为凭证
+ */
+func withCacheCredentials(ctx context.Context, rules RedactRules) context.Context {
+	return context.WithValue(ctx, cacheCredentialsKey{}, rules)
+}
+
+func cacheCredentials(ctx context.Context) RedactRules {
+	if rules, ok := ctx.Value(cacheCredentialsKey{}).(RedactRules); ok {
+		return rules
+	}
+	return DefaultRedactRules()
+}
+
+// cacheEntry 缓存的响应
+type cacheEntry struct {
+	StatusCode   int
+	Header       http.Header
+	Body         []byte
+	RequestTime  time.Time
+	ResponseTime time.Time
+	Vary         map[string]string // Vary请求头及请求时的值
+}
+
+// cacheTransport 缓存层，位于中间件外层
+type cacheTransport struct {
+	next       http.RoundTripper
+	storage    CacheStorage
+	now        func() time.Time
+	mu         sync.Mutex
+	revalidate map[string]bool // 后台重新验证中的key
+}
+
+func newCacheTransport(next http.RoundTripper, storage CacheStorage) *cacheTransport {
+	return &cacheTransport{
+		next:       next,
+		storage:    storage,
+		now:        time.Now,
+		revalidate: map[string]bool{},
+	}
+}
+
+func cacheKey(method, url string) string {
+	return method + " " + url
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := cacheKey(http.MethodGet, req.URL.String())
+
+	if req.Method != http.MethodGet {
+		resp, err := t.next.RoundTrip(req)
+		// 不安全的方法成功后使缓存失效
+		if err == nil && !isSafeMethod(req.Method) && resp.StatusCode < 400 {
+			t.storage.Delete(key)
+		}
+		return resp, err
+	}
+
+	// 自行设置了条件请求或范围请求的不处理
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" || req.Header.Get("Range") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	reqCC := parseCacheControl(req.Header)
+	if _, ok := reqCC["no-store"]; ok {
+		return t.next.RoundTrip(req)
+	}
+
+	entry := t.load(key)
+	if entry != nil && !entry.varyMatches(req) {
+		entry = nil
+	}
+	_, onlyIfCached := reqCC["only-if-cached"]
+	if entry == nil {
+		if onlyIfCached {
+			return gatewayTimeout(req), nil
+		}
+		return t.fetch(key, req)
+	}
+
+	now := t.now()
+	age := entry.age(now)
+	lifetime := entry.freshnessLifetime()
+	respCC := parseCacheControl(entry.Header)
+
+	fresh := age < lifetime
+	if v, ok := reqCC["max-age"]; ok && age > parseSeconds(v) {
+		fresh = false
+	}
+	if v, ok := reqCC["min-fresh"]; ok && lifetime-age < parseSeconds(v) {
+		fresh = false
+	}
+	_, reqNoCache := reqCC["no-cache"]
+	_, respNoCache := respCC["no-cache"]
+	noCache := reqNoCache || respNoCache ||
+		(req.Header.Get("Cache-Control") == "" && strings.Contains(req.Header.Get("Pragma"), "no-cache"))
+
+	if !noCache {
+		if fresh {
+			setCacheStatus(req.Context(), CacheHit)
+			return entry.response(req, age), nil
+		}
+
+		_, mustRevalidate := respCC["must-revalidate"]
+		staleness := age - lifetime
+		if v, ok := reqCC["max-stale"]; ok && !mustRevalidate && (v == "" || staleness <= parseSeconds(v)) {
+			setCacheStatus(req.Context(), CacheStale)
+			return entry.response(req, age), nil
+		}
+		if v, ok := respCC["stale-while-revalidate"]; ok && !mustRevalidate && staleness <= parseSeconds(v) {
+			t.revalidateBackground(key, req, entry)
+			setCacheStatus(req.Context(), CacheStale)
+			return entry.response(req, age), nil
+		}
+	}
+
+	if onlyIfCached {
+		return gatewayTimeout(req), nil
+	}
+	return t.revalidateEntry(key, req, entry)
+}
+
+/**
+ * 从服务端获取，可缓存的响应在Body读取完成后保存
+ */
+func (t *cacheTransport) fetch(key string, req *http.Request) (*http.Response, error) {
+	requestTime := t.now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	setCacheStatus(req.Context(), CacheMiss)
+	t.storeOnEOF(key, req, resp, requestTime)
+	return resp, nil
+}
+
+/**
+ * 携带 If-None-Match/If-Modified-Since 重新验证缓存
+ */
+func (t *cacheTransport) revalidateEntry(key string, req *http.Request, entry *cacheEntry) (*http.Response, error) {
+	etag := entry.Header.Get("ETag")
+	lastModified := entry.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return t.fetch(key, req)
+	}
+
+	conditional := req.Clone(req.Context())
+	if etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	requestTime := t.now()
+	resp, err := t.next.RoundTrip(conditional)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		setCacheStatus(req.Context(), CacheMiss)
+		t.storeOnEOF(key, req, resp, requestTime)
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	// 使用304响应的头更新缓存，entry可能同时被其他请求使用，更新副本
+	updated := *entry
+	updated.Header = entry.Header.Clone()
+	for k, v := range resp.Header {
+		if k == "Content-Length" {
+			continue
+		}
+		updated.Header[k] = v
+	}
+	updated.RequestTime = requestTime
+	updated.ResponseTime = t.now()
+	t.save(key, &updated)
+
+	setCacheStatus(req.Context(), CacheRevalidated)
+	return updated.response(req, updated.age(t.now())), nil
+}
+
+/**
+ * 后台重新验证，同一个key同时只有一个
+ */
+func (t *cacheTransport) revalidateBackground(key string, req *http.Request, entry *cacheEntry) {
+	t.mu.Lock()
+	if t.revalidate[key] {
+		t.mu.Unlock()
+		return
+	}
+	t.revalidate[key] = true
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(withCacheCredentials(context.Background(), cacheCredentials(req.Context())), cacheRevalidateTimeout)
+	background := req.Clone(ctx)
+	go func() {
+		defer func() {
+			cancel()
+			t.mu.Lock()
+			delete(t.revalidate, key)
+			t.mu.Unlock()
+		}()
+		resp, err := t.revalidateEntry(key, background, entry)
+		if err != nil {
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+}
+
+func (t *cacheTransport) storeOnEOF(key string, req *http.Request, resp *http.Response, requestTime time.Time) {
+	if !isStorable(req, resp) {
+		return
+	}
+	entry := &cacheEntry{
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header.Clone(),
+		RequestTime: requestTime,
+		Vary:        map[string]string{},
+	}
+	for _, name := range varyHeaders(resp.Header) {
+		entry.Vary[name] = strings.Join(req.Header.Values(name), ",")
+	}
+	if entry.freshnessLifetime() <= 0 && entry.Header.Get("ETag") == "" && entry.Header.Get("Last-Modified") == "" {
+		return
+	}
+	resp.Body = &cacheBody{
+		ReadCloser: resp.Body,
+		onEOF: func(body []byte) {
+			entry.Body = body
+			entry.ResponseTime = t.now()
+			t.save(key, entry)
+		},
+	}
+}
+
+func (t *cacheTransport) load(key string) *cacheEntry {
+	data, ok := t.storage.Get(key)
+	if !ok {
+		return nil
+	}
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		t.storage.Delete(key)
+		return nil
+	}
+	return entry
+}
+
+func (t *cacheTransport) save(key string, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	t.storage.Set(key, data)
+}
+
+/**
+ * 判断响应是否可以保存
+ */
+func isStorable(req *http.Request, resp *http.Response) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	if _, ok := parseCacheControl(req.Header)["no-store"]; ok {
+		return false
+	}
+	cc := parseCacheControl(resp.Header)
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	// 携带凭证的请求的响应只属于该凭证，响应明确允许时才保存 RFC 9111 3.5
+	if hasCredentials(req) {
+		_, public := cc["public"]
+		_, sMaxAge := cc["s-maxage"]
+		_, mustRevalidate := cc["must-revalidate"]
+		if !public && !sMaxAge && !mustRevalidate {
+			return false
+		}
+	}
+	for _, name := range varyHeaders(resp.Header) {
+		if name == "*" {
+			return false
+		}
+	}
+	if _, ok := cc["max-age"]; ok {
+		return true
+	}
+	if _, ok := cc["public"]; ok {
+		return true
+	}
+	if resp.Header.Get("Expires") != "" {
+		return true
+	}
+	return isHeuristicallyCacheable(resp.StatusCode)
+}
+
+/**
+ * 请求是否携带凭证：Authorization、Cookie，以及脱敏规则中的请求头和URL参数
+ */
+func hasCredentials(req *http.Request) bool {
+	if req.Header.Get("Authorization") != "" || req.Header.Get("Cookie") != "" || req.URL.User != nil {
+		return true
+	}
+	rules := cacheCredentials(req.Context())
+	for k, v := range req.Header {
+		if len(v) > 0 && containsFold(rules.Headers, k) {
+			return true
+		}
+	}
+	for k := range req.URL.Query() {
+		if containsFold(rules.QueryParams, k) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 默认可缓存的状态码 RFC 9110 15.1
+ */
+func isHeuristicallyCacheable(status int) bool {
+	switch status {
+	case 200, 203, 204, 300, 301, 308, 404, 405, 410, 414, 501:
+		return true
+	}
+	return false
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func varyHeaders(h http.Header) []string {
+	names := []string{}
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name != "*" {
+				name = http.CanonicalHeaderKey(name)
+			}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (e *cacheEntry) varyMatches(req *http.Request) bool {
+	for name, value := range e.Vary {
+		if strings.Join(req.Header.Values(name), ",") != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *cacheEntry) date() time.Time {
+	if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		return date
+	}
+	return e.ResponseTime
+}
+
+/**
+ * 新鲜期 RFC 9111 4.2.1
+ */
+func (e *cacheEntry) freshnessLifetime() time.Duration {
+	cc := parseCacheControl(e.Header)
+	if v, ok := cc["max-age"]; ok {
+		return parseSeconds(v)
+	}
+	if expires := e.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(e.date())
+	}
+	if !isHeuristicallyCacheable(e.StatusCode) {
+		return 0
+	}
+	// 启发式新鲜期，取Last-Modified到当时的10%
+	if lm, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil {
+		if d := e.date().Sub(lm); d > 0 {
+			return d / 10
+		}
+	}
+	return 0
+}
+
+/**
+ * 缓存年龄 RFC 9111 4.2.3
+ */
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	apparentAge := e.ResponseTime.Sub(e.date())
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+	ageValue := parseSeconds(e.Header.Get("Age"))
+	correctedAge := ageValue + e.ResponseTime.Sub(e.RequestTime)
+	if correctedAge < apparentAge {
+		correctedAge = apparentAge
+	}
+	return correctedAge + now.Sub(e.ResponseTime)
+}
+
+func (e *cacheEntry) response(req *http.Request, age time.Duration) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+func gatewayTimeout(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     "504 Gateway Timeout",
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+/**
+ * 解析Cache-Control，指令名转为小写
+ */
+func parseCacheControl(h http.Header) map[string]string {
+	cc := map[string]string{}
+	for _, v := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, value, _ := strings.Cut(directive, "=")
+			cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return cc
+}
+
+func parseSeconds(v string) time.Duration {
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// cacheBody 读取完成时保存响应，超过大小限制或未读完时不保存
+type cacheBody struct {
+	io.ReadCloser
+	buf      bytes.Buffer
+	onEOF    func(body []byte)
+	done     bool
+	overflow bool
+}
+
+func (b *cacheBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if !b.overflow {
+		if b.buf.Len()+n > maxCacheBodySize {
+			b.overflow = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF && !b.done && !b.overflow {
+		b.done = true
+		b.onEOF(b.buf.Bytes())
+	}
+	return n, err
+}
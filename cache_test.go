@@ -0,0 +1,385 @@
+package curlx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 可调整的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Add(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func newCachedCurlx(storage CacheStorage) (*Curlx, *fakeClock) {
+	c := NewCurlx(WithOptionCache(storage))
+	clock := &fakeClock{now: time.Now()}
+	c.roundTripper.(*cacheTransport).now = clock.Now
+	return c, clock
+}
+
+func cacheGet(t *testing.T, c *Curlx, url string, ps ...Param) (string, CacheStatus) {
+	t.Helper()
+	ps = append([]Param{SetParamsUrl(url), SetParamsMethod(MethodGet)}, ps...)
+	resp := c.SendWithResponse(context.Background(), ps...)
+	defer resp.Close()
+	body, err := resp.GetBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body), resp.GetCacheStatus()
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("config"))
+	}))
+	defer srv.Close()
+
+	c, clock := newCachedCurlx(NewMemoryCache(10))
+	if body, status := cacheGet(t, c, srv.URL); body != "config" || status != CacheMiss {
+		t.Fatalf("first = %q %v", body, status)
+	}
+	if body, status := cacheGet(t, c, srv.URL); body != "config" || status != CacheHit {
+		t.Fatalf("second = %q %v", body, status)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+
+	// 请求要求不使用缓存
+	if _, status := cacheGet(t, c, srv.URL, SetParamsHeader("Cache-Control", "no-cache")); status != CacheMiss {
+		t.Errorf("no-cache status = %v", status)
+	}
+
+	clock.Add(2 * time.Minute)
+	if _, status := cacheGet(t, c, srv.URL); status != CacheMiss {
+		t.Errorf("expired status = %v", status)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestCacheRevalidate(t *testing.T) {
+	var calls, notModified int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/modified":
+			lm := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+			w.Header().Set("Cache-Control", "max-age=0")
+			w.Header().Set("Last-Modified", lm)
+			if r.Header.Get("If-Modified-Since") != "" {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Write([]byte("catalog"))
+	}))
+	defer srv.Close()
+
+	c, _ := newCachedCurlx(NewMemoryCache(10))
+	for _, path := range []string{"/etag", "/modified"} {
+		if _, status := cacheGet(t, c, srv.URL+path); status != CacheMiss {
+			t.Errorf("%s first status = %v", path, status)
+		}
+		body, status := cacheGet(t, c, srv.URL+path)
+		if body != "catalog" || status != CacheRevalidated {
+			t.Errorf("%s second = %q %v", path, body, status)
+		}
+	}
+	if calls != 4 || notModified != 2 {
+		t.Errorf("calls = %d, notModified = %d", calls, notModified)
+	}
+}
+
+func TestCacheVary(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer srv.Close()
+
+	c, _ := newCachedCurlx(NewMemoryCache(10))
+	cacheGet(t, c, srv.URL, SetParamsHeader("Accept-Language", "en"))
+	if body, status := cacheGet(t, c, srv.URL, SetParamsHeader("Accept-Language", "en")); body != "en" || status != CacheHit {
+		t.Errorf("same variant = %q %v", body, status)
+	}
+	if body, status := cacheGet(t, c, srv.URL, SetParamsHeader("Accept-Language", "zh")); body != "zh" || status != CacheMiss {
+		t.Errorf("other variant = %q %v", body, status)
+	}
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	var calls int32
+	revalidated := make(chan struct{}, 1)
+	c, clock := newCachedCurlx(NewMemoryCache(10))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Date", clock.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Cache-Control", "max-age=1, stale-while-revalidate=60")
+		if n > 1 {
+			w.Write([]byte("v2"))
+			select {
+			case revalidated <- struct{}{}:
+			default:
+			}
+			return
+		}
+		w.Write([]byte("v1"))
+	}))
+	defer srv.Close()
+
+	cacheGet(t, c, srv.URL)
+	clock.Add(10 * time.Second)
+
+	if body, status := cacheGet(t, c, srv.URL); body != "v1" || status != CacheStale {
+		t.Fatalf("stale = %q %v", body, status)
+	}
+	select {
+	case <-revalidated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("background revalidation not started")
+	}
+
+	// 等待后台请求保存
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		body, status := cacheGet(t, c, srv.URL)
+		if body == "v2" && status == CacheHit {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("after revalidate = %q %v", body, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// 后台重新验证时前台仍在使用同一份缓存，需要 go test -race 检查
+// 直接使用 cacheTransport 和真实时钟，避免连接池、fakeClock 等的锁掩盖数据竞争
+func TestCacheStaleWhileRevalidateNotModified(t *testing.T) {
+	next := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+		header.Set("Cache-Control", "max-age=0, stale-while-revalidate=60")
+		header.Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			header.Set("X-Revalidated", "1")
+			return &http.Response{StatusCode: http.StatusNotModified, Header: header, Body: http.NoBody, Request: r}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader("v1")), Request: r}, nil
+	})
+	ct := newCacheTransport(next, NewMemoryCache(10))
+
+	get := func() (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com/a", nil)
+		resp, err := ct.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	get()
+	if resp, body := get(); body != "v1" {
+		t.Fatalf("stale = %q %v", body, resp.Header)
+	}
+
+	// 等待后台请求用304的响应头更新缓存
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, body := get()
+		if body == "v1" && resp.Header.Get("X-Revalidated") == "1" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("after revalidate = %q %v", body, resp.Header)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCacheNoStoreAndInvalidate(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "no-store")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c, _ := newCachedCurlx(NewMemoryCache(10))
+	cacheGet(t, c, srv.URL+"/private")
+	if _, status := cacheGet(t, c, srv.URL+"/private"); status != CacheMiss {
+		t.Errorf("no-store status = %v", status)
+	}
+
+	cacheGet(t, c, srv.URL+"/item")
+	if _, err := c.Send(context.Background(), SetParamsUrl(srv.URL+"/item"), SetParamsMethod(MethodPost)); err != nil {
+		t.Fatal(err)
+	}
+	if _, status := cacheGet(t, c, srv.URL+"/item"); status != CacheMiss {
+		t.Errorf("after POST status = %v", status)
+	}
+	if _, status := cacheGet(t, c, srv.URL+"/missing", SetParamsHeader("Cache-Control", "only-if-cached")); status != CacheNone {
+		t.Errorf("only-if-cached status = %v", status)
+	}
+}
+
+func TestMemoryCacheLRU(t *testing.T) {
+	m := NewMemoryCache(2)
+	m.Set("a", []byte("1"))
+	m.Set("b", []byte("2"))
+	m.Get("a")
+	m.Set("c", []byte("3"))
+	if _, ok := m.Get("b"); ok {
+		t.Error("b should be evicted")
+	}
+	if v, ok := m.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("a = %q %v", v, ok)
+	}
+	if m.Len() != 2 {
+		t.Errorf("len = %d", m.Len())
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	d, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("disk"))
+	}))
+	defer srv.Close()
+
+	cacheGet(t, NewCurlx(WithOptionCache(d)), srv.URL)
+	// 新的客户端共享磁盘缓存
+	if body, status := cacheGet(t, NewCurlx(WithOptionCache(d)), srv.URL); body != "disk" || status != CacheHit {
+		t.Errorf("disk = %q %v", body, status)
+	}
+	d.Delete(cacheKey(http.MethodGet, srv.URL))
+	if _, ok := d.Get(cacheKey(http.MethodGet, srv.URL)); ok {
+		t.Error("entry should be deleted")
+	}
+}
+
+func TestCacheAuthorization(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/public" {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	c, _ := newCachedCurlx(NewMemoryCache(10))
+	for _, token := range []string{"alice", "bob", "alice"} {
+		body, status := cacheGet(t, c, srv.URL+"/me", SetParamsAuth(BearerAuth(token)))
+		if body != "Bearer "+token || status != CacheMiss {
+			t.Errorf("%s = %q %v", token, body, status)
+		}
+	}
+	if body, status := cacheGet(t, c, srv.URL+"/me", SetParamsHeader("Authorization", "Bearer carol")); body != "Bearer carol" || status != CacheMiss {
+		t.Errorf("header auth = %q %v", body, status)
+	}
+	if calls != 4 {
+		t.Errorf("calls = %d, want 4", calls)
+	}
+
+	// 响应明确允许共享时可以缓存
+	cacheGet(t, c, srv.URL+"/public", SetParamsAuth(BearerAuth("alice")))
+	if _, status := cacheGet(t, c, srv.URL+"/public", SetParamsAuth(BearerAuth("bob"))); status != CacheHit {
+		t.Errorf("public status = %v", status)
+	}
+}
+
+func TestCacheCredentials(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(r.Header.Get("X-Api-Key") + r.Header.Get("X-Tenant-Key") + r.URL.Query().Get("key") + r.Header.Get("Cookie")))
+	}))
+	defer srv.Close()
+
+	c, _ := newCachedCurlx(NewMemoryCache(10))
+	cases := []struct {
+		want string
+		ps   []Param
+	}{
+		{"alice", []Param{SetParamsHeader("X-Api-Key", "alice")}},
+		{"bob", []Param{SetParamsHeader("X-Api-Key", "bob")}},
+		{"alice", []Param{SetParamsAuth(APIKeyAuth("X-Tenant-Key", "alice", APIKeyInHeader))}},
+		{"bob", []Param{SetParamsAuth(APIKeyAuth("X-Tenant-Key", "bob", APIKeyInHeader))}},
+		{"sid=alice", []Param{SetCookie("sid", "alice")}},
+		{"sid=bob", []Param{SetCookie("sid", "bob")}},
+	}
+	for _, tc := range cases {
+		for i := 0; i < 2; i++ {
+			if body, status := cacheGet(t, c, srv.URL+"/me", tc.ps...); body != tc.want || status != CacheMiss {
+				t.Errorf("%s = %q %v", tc.want, body, status)
+			}
+		}
+	}
+
+	// URL参数中的API Key
+	c, _ = newCachedCurlx(NewMemoryCache(10))
+	for i := 0; i < 2; i++ {
+		if _, status := cacheGet(t, c, srv.URL+"/me", SetParamsAuth(APIKeyAuth("key", "alice", APIKeyInQuery))); status != CacheMiss {
+			t.Errorf("query api key status = %v", status)
+		}
+	}
+	if calls != int32(len(cases)*2+2) {
+		t.Errorf("calls = %d, want %d", calls, len(cases)*2+2)
+	}
+}
@@ -0,0 +1,791 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 常见缩写，生成的名称中保持大写
+var initialisms = map[string]bool{
+	"api": true, "db": true, "dns": true, "html": true, "http": true, "https": true, "id": true,
+	"ip": true, "json": true, "sql": true, "ssh": true, "tls": true, "ttl": true, "ui": true,
+	"uid": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// generator 把 OpenAPI 文档生成为Go客户端代码
+type generator struct {
+	doc     *openAPI
+	pkg     string
+	types   bytes.Buffer      // 组件类型
+	inline  bytes.Buffer      // 内联对象生成的类型
+	ops     bytes.Buffer      // 请求结构体、错误类型和方法
+	structs map[string]bool   // 生成为结构体的类型
+	names   map[string]string // 已使用的类型名及来源，用于检查冲突
+}
+
+// 生成代码可能用到的标准库
+var stdImports = []struct{ name, path string }{
+	{"context", "context"},
+	{"json", "encoding/json"},
+	{"fmt", "fmt"},
+	{"time", "time"},
+}
+
+/**
+ * 按代码中实际引用的包选择导入，注释和字符串中的内容不影响结果
+ */
+func usedImports(code string) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n\n"+code, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parse generated code: %w", err)
+	}
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+	imports := []string{}
+	for _, imp := range stdImports {
+		if used[imp.name] {
+			imports = append(imports, imp.path)
+		}
+	}
+	return imports, nil
+}
+
+type endpoint struct {
+	path   string
+	method string
+	item   pathItem
+	op     *operation
+}
+
+/**
+ * 生成客户端代码
+ * @param pkg 生成代码的包名
+ */
+func generate(doc *openAPI, pkg string) ([]byte, error) {
+	g := &generator{doc: doc, pkg: pkg, structs: map[string]bool{}, names: map[string]string{}}
+	if err := g.run(); err != nil {
+		return nil, err
+	}
+	return g.source()
+}
+
+func (g *generator) run() error {
+	schemaNames := sortedKeys(g.doc.Components.Schemas)
+
+	// 先登记组件中的结构体，字段引用时据此决定是否使用指针
+	for _, name := range schemaNames {
+		if err := g.claim(goName(name), "schema "+name); err != nil {
+			return err
+		}
+		if isObject(g.doc.Components.Schemas[name]) {
+			g.structs[goName(name)] = true
+		}
+	}
+	for _, name := range schemaNames {
+		if err := g.component(name, g.doc.Components.Schemas[name]); err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	endpoints := []endpoint{}
+	for _, path := range sortedKeys(g.doc.Paths) {
+		item := g.doc.Paths[path]
+		for _, o := range item.operations() {
+			endpoints = append(endpoints, endpoint{path: path, method: o.method, item: item, op: o.op})
+		}
+	}
+	for _, e := range endpoints {
+		if err := g.operation(e); err != nil {
+			return fmt.Errorf("%s %s: %w", e.method, e.path, err)
+		}
+	}
+	return nil
+}
+
+/**
+ * 组装文件并格式化
+ */
+func (g *generator) source() ([]byte, error) {
+	var body bytes.Buffer
+	title := strings.TrimSpace(g.doc.Info.Title + " " + g.doc.Info.Version)
+	fmt.Fprintf(&body, "// Client %s 客户端\n", title)
+	body.WriteString(`type Client struct {
+	c *curlx.Curlx
+}
+
+// NewClient 创建客户端，请求路径相对 baseURL 解析
+func NewClient(baseURL string, opts ...curlx.Option) *Client {
+	opts = append([]curlx.Option{curlx.WithOptionBaseURL(baseURL)}, opts...)
+	return &Client{c: curlx.NewCurlx(opts...)}
+}
+
+// APIError 文档中没有声明的响应状态码
+type APIError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+`)
+	body.Write(g.types.Bytes())
+	body.Write(g.inline.Bytes())
+	body.Write(g.ops.Bytes())
+
+	code := body.String()
+	imports, err := usedImports(code)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by curlx-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", g.pkg)
+	out.WriteString("import (\n")
+	for _, imp := range imports {
+		fmt.Fprintf(&out, "\t%s\n", strconv.Quote(imp))
+	}
+	out.WriteString("\n\t\"github.com/yuninks/curlx\"\n)\n\n")
+	out.WriteString(code)
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+/**
+ * 登记类型名，重名时返回错误
+ */
+func (g *generator) claim(name, source string) error {
+	if prev, ok := g.names[name]; ok {
+		return fmt.Errorf("type name %s of %s conflicts with %s", name, source, prev)
+	}
+	g.names[name] = source
+	return nil
+}
+
+/**
+ * 生成 components/schemas 中的类型
+ */
+func (g *generator) component(name string, s *schema) error {
+	typeName := goName(name)
+	if isObject(s) {
+		return g.defineStruct(&g.types, typeName, s)
+	}
+	writeComment(&g.types, typeName, s.Description)
+	if s.Type == "string" && len(s.Enum) > 0 {
+		fmt.Fprintf(&g.types, "type %s string\n\nconst (\n", typeName)
+		for _, v := range s.Enum {
+			value := fmt.Sprint(v)
+			fmt.Fprintf(&g.types, "\t%s%s %s = %q\n", typeName, goName(value), typeName, value)
+		}
+		g.types.WriteString(")\n\n")
+		return nil
+	}
+	t, err := g.goType(s, typeName+"Item")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&g.types, "type %s %s\n\n", typeName, t)
+	return nil
+}
+
+/**
+ * 生成结构体，allOf 的属性合并到一起
+ */
+func (g *generator) defineStruct(buf *bytes.Buffer, typeName string, s *schema) error {
+	props := map[string]*schema{}
+	required := map[string]bool{}
+	if err := g.collectProperties(s, props, required); err != nil {
+		return err
+	}
+
+	// 嵌套的内联对象会先写入，结构体完成后再写入 buf
+	var b bytes.Buffer
+	writeComment(&b, typeName, s.Description)
+	fmt.Fprintf(&b, "type %s struct {\n", typeName)
+	fields := map[string]bool{}
+	for _, prop := range sortedKeys(props) {
+		field := uniqueName(goName(prop), fields)
+		t, err := g.goType(props[prop], typeName+field)
+		if err != nil {
+			return fmt.Errorf("property %s: %w", prop, err)
+		}
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+			if g.structs[t] {
+				t = "*" + t
+			}
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:%q`%s\n", field, t, tag, lineComment(props[prop].Description))
+	}
+	b.WriteString("}\n\n")
+	buf.Write(b.Bytes())
+	return nil
+}
+
+func (g *generator) collectProperties(s *schema, props map[string]*schema, required map[string]bool) error {
+	s, err := g.doc.schema(s)
+	if err != nil {
+		return err
+	}
+	for _, sub := range s.AllOf {
+		if err := g.collectProperties(sub, props, required); err != nil {
+			return err
+		}
+	}
+	for name, prop := range s.Properties {
+		props[name] = prop
+	}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	return nil
+}
+
+/**
+ * schema 对应的Go类型，内联对象生成名为 name 的结构体
+ */
+func (g *generator) goType(s *schema, name string) (string, error) {
+	if s == nil {
+		return "any", nil
+	}
+	if s.Ref != "" {
+		ref, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return "", err
+		}
+		if _, ok := g.doc.Components.Schemas[ref]; !ok {
+			return "", fmt.Errorf("schema %q not found", s.Ref)
+		}
+		return goName(ref), nil
+	}
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		return g.goType(s.AllOf[0], name)
+	}
+	if isObject(s) {
+		if err := g.claim(name, "inline object"); err != nil {
+			return "", err
+		}
+		g.structs[name] = true
+		if err := g.defineStruct(&g.inline, name, s); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+
+	switch s.Type {
+	case "object":
+		if extra, ok := s.additional(); ok {
+			t, err := g.goType(extra, name+"Value")
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + t, nil
+		}
+		return "map[string]any", nil
+	case "array":
+		t, err := g.goType(s.Items, name+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + t, nil
+	case "string":
+		if s.Format == "date-time" {
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "integer":
+		if s.Format == "int32" {
+			return "int32", nil
+		}
+		return "int64", nil
+	case "number":
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	}
+	return "any", nil
+}
+
+// field 请求结构体的字段
+type field struct {
+	name  string
+	param *parameter
+	typ   string
+}
+
+/**
+ * 生成一个操作的请求结构体、错误类型和方法
+ */
+func (g *generator) operation(e endpoint) error {
+	op := e.op
+	opName := goName(op.OperationID)
+	if op.OperationID == "" {
+		opName = goName(strings.ToLower(e.method) + " " + strings.NewReplacer("{", "by ", "}", "").Replace(e.path))
+	}
+
+	params, err := g.parameters(e.item.Parameters, op.Parameters)
+	if err != nil {
+		return err
+	}
+
+	// 请求Body
+	var bodyType, contentType string
+	bodyRequired := false
+	if op.RequestBody != nil {
+		rb, err := g.doc.requestBody(op.RequestBody)
+		if err != nil {
+			return err
+		}
+		bodyRequired = rb.Required
+		s, ok := jsonContent(rb.Content)
+		contentType = "curlx.ContentTypeJson"
+		if !ok {
+			if m, found := rb.Content["application/x-www-form-urlencoded"]; found && m.Schema != nil {
+				s, contentType = m.Schema, "curlx.ContentTypeUrlEncoded"
+			} else {
+				return fmt.Errorf("unsupported request body content type %v", sortedKeys(rb.Content))
+			}
+		}
+		if bodyType, err = g.goType(s, opName+"RequestBody"); err != nil {
+			return err
+		}
+		if !bodyRequired && g.structs[bodyType] {
+			bodyType = "*" + bodyType
+		}
+	}
+
+	// 请求结构体
+	hasRequest := len(params) > 0 || bodyType != ""
+	requestName := opName + "Request"
+	fields := []field{}
+	if hasRequest {
+		if err := g.claim(requestName, "operation "+opName); err != nil {
+			return err
+		}
+		used := map[string]bool{"Body": bodyType != ""}
+		fmt.Fprintf(&g.ops, "// %s %s 的请求参数\ntype %s struct {\n", requestName, opName, requestName)
+		for _, p := range params {
+			name := goName(p.Name)
+			if used[name] {
+				name += goName(p.In)
+			}
+			name = uniqueName(name, used)
+			t, err := g.goType(p.Schema, opName+name)
+			if err != nil {
+				return fmt.Errorf("parameter %s: %w", p.Name, err)
+			}
+			tag := "-"
+			if p.In == "query" {
+				tag = p.Name
+				if !p.Required {
+					tag += ",omitempty"
+				}
+			}
+			fmt.Fprintf(&g.ops, "\t%s %s `url:%q`%s\n", name, t, tag, lineComment(fmt.Sprintf("%s %s", p.In, p.Description)))
+			fields = append(fields, field{name: name, param: p, typ: t})
+		}
+		if bodyType != "" {
+			fmt.Fprintf(&g.ops, "\tBody %s `url:\"-\"`\n", bodyType)
+		}
+		g.ops.WriteString("}\n\n")
+	}
+
+	// 响应
+	codes := sortedKeys(op.Responses)
+	sort.SliceStable(codes, func(i, j int) bool { return statusOrder(codes[i]) < statusOrder(codes[j]) })
+	var resultType, resultCode string
+	for _, code := range codes {
+		if !isSuccess(code) {
+			continue
+		}
+		resp, err := g.doc.response(op.Responses[code])
+		if err != nil {
+			return err
+		}
+		if s, ok := jsonContent(resp.Content); ok {
+			if resultType, err = g.goType(s, opName+"Response"); err != nil {
+				return err
+			}
+			if g.structs[resultType] {
+				resultType = "*" + resultType
+			}
+			resultCode = code
+			break
+		}
+	}
+	ret := func(v string) string {
+		if resultType == "" {
+			return "return " + v
+		}
+		return "return out, " + v
+	}
+
+	// 方法
+	var m bytes.Buffer
+	summary := strings.TrimSpace(op.Summary)
+	if summary == "" {
+		summary = firstLine(op.Description)
+	}
+	if summary == "" {
+		fmt.Fprintf(&m, "// %s %s %s\n", opName, e.method, e.path)
+	} else {
+		fmt.Fprintf(&m, "// %s %s\n//\n// %s %s\n", opName, summary, e.method, e.path)
+	}
+	if op.Deprecated {
+		m.WriteString("//\n// Deprecated: 接口已废弃\n")
+	}
+	signature := "ctx context.Context"
+	if hasRequest {
+		signature += ", req *" + requestName
+	}
+	if resultType != "" {
+		fmt.Fprintf(&m, "func (c *Client) %s(%s) (%s, error) {\n\tvar out %s\n", opName, signature, resultType, resultType)
+	} else {
+		fmt.Fprintf(&m, "func (c *Client) %s(%s) error {\n", opName, signature)
+	}
+
+	if hasRequest {
+		fmt.Fprintf(&m, "\tif req == nil {\n\t\treq = &%s{}\n\t}\n", requestName)
+	}
+
+	method := fmt.Sprintf("curlx.Method(%q)", e.method)
+	switch e.method {
+	case "GET":
+		method = "curlx.MethodGet"
+	case "POST":
+		method = "curlx.MethodPost"
+	}
+	fmt.Fprintf(&m, "\tps := []curlx.Param{\n\t\tcurlx.SetParamsMethod(%s),\n\t\tcurlx.SetParamsUrl(%q),\n", method, strings.TrimPrefix(e.path, "/"))
+	hasQuery := false
+	for _, f := range fields {
+		switch f.param.In {
+		case "path":
+			fmt.Fprintf(&m, "\t\tcurlx.SetParamsPathParam(%q, fmt.Sprint(req.%s)),\n", f.param.Name, f.name)
+		case "query":
+			hasQuery = true
+		}
+	}
+	if hasQuery {
+		m.WriteString("\t\tcurlx.SetParamsQueryStruct(req),\n")
+	}
+	m.WriteString("\t}\n")
+	for _, f := range fields {
+		var add string
+		switch f.param.In {
+		case "header":
+			add = fmt.Sprintf("curlx.SetParamsHeader(%q, fmt.Sprint(req.%s))", f.param.Name, f.name)
+		case "cookie":
+			add = fmt.Sprintf("curlx.SetCookie(%q, fmt.Sprint(req.%s))", f.param.Name, f.name)
+		default:
+			continue
+		}
+		if cond := nonZero("req."+f.name, f.typ, g.structs); cond != "" && !f.param.Required {
+			fmt.Fprintf(&m, "\tif %s {\n\t\tps = append(ps, %s)\n\t}\n", cond, add)
+		} else {
+			fmt.Fprintf(&m, "\tps = append(ps, %s)\n", add)
+		}
+	}
+	if bodyType != "" {
+		add := fmt.Sprintf("ps = append(ps, curlx.SetParamsBodyAny(req.Body), curlx.SetParamsContentType(%s))", contentType)
+		if strings.HasPrefix(bodyType, "*") {
+			fmt.Fprintf(&m, "\tif req.Body != nil {\n\t\t%s\n\t}\n", add)
+		} else {
+			fmt.Fprintf(&m, "\t%s\n", add)
+		}
+	}
+
+	fmt.Fprintf(&m, `
+	resp := c.c.SendWithResponse(ctx, ps...)
+	defer resp.Close()
+	body, err := resp.GetBody()
+	if err != nil {
+		%s
+	}
+
+	switch status := resp.GetStatusCode(); {
+`, ret("err"))
+
+	hasSuccessRange := false
+	hasDefault := false
+	for _, code := range codes {
+		if code == "default" {
+			hasDefault = true
+			continue
+		}
+		fmt.Fprintf(&m, "\tcase %s:\n", statusCondition(code))
+		if isSuccess(code) {
+			if code == "2XX" {
+				hasSuccessRange = true
+			}
+			if code == resultCode {
+				m.WriteString("\t\tif len(body) > 0 {\n\t\t\terr = json.Unmarshal(body, &out)\n\t\t}\n\t\treturn out, err\n")
+			} else {
+				fmt.Fprintf(&m, "\t\t%s\n", ret("nil"))
+			}
+			continue
+		}
+		errName, err := g.errorType(opName, op.OperationID, code, op.Responses[code])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&m, "\t\t%s\n", ret(errName+"(status, body)"))
+	}
+	if !hasSuccessRange {
+		fmt.Fprintf(&m, "\tcase status >= 200 && status < 300:\n\t\t%s\n", ret("nil"))
+	}
+	m.WriteString("\tdefault:\n")
+	if hasDefault {
+		errName, err := g.errorType(opName, op.OperationID, "default", op.Responses["default"])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&m, "\t\t%s\n", ret(errName+"(status, body)"))
+	} else {
+		fmt.Fprintf(&m, "\t\t%s\n", ret("&APIError{StatusCode: status, Body: body}"))
+	}
+	m.WriteString("\t}\n}\n\n")
+
+	g.ops.Write(m.Bytes())
+	return nil
+}
+
+/**
+ * 合并路径和操作的参数，操作中同名同位置的参数覆盖路径的参数
+ */
+func (g *generator) parameters(lists ...[]*parameter) ([]*parameter, error) {
+	params := []*parameter{}
+	index := map[string]int{}
+	for _, list := range lists {
+		for _, p := range list {
+			p, err := g.doc.parameter(p)
+			if err != nil {
+				return nil, err
+			}
+			key := p.In + ":" + p.Name
+			if i, ok := index[key]; ok {
+				params[i] = p
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, p)
+		}
+	}
+	return params, nil
+}
+
+/**
+ * 生成状态码对应的错误类型，返回构造函数名
+ */
+func (g *generator) errorType(opName, operationID, code string, r *response) (string, error) {
+	resp, err := g.doc.response(r)
+	if err != nil {
+		return "", err
+	}
+	typeName := opName + statusName(code) + "Error"
+	if err := g.claim(typeName, "response "+code+" of "+opName); err != nil {
+		return "", err
+	}
+	bodyType := ""
+	if s, ok := jsonContent(resp.Content); ok {
+		if bodyType, err = g.goType(s, typeName+"Body"); err != nil {
+			return "", err
+		}
+	}
+
+	label := operationID
+	if label == "" {
+		label = opName
+	}
+	desc := strings.TrimSpace(firstLine(resp.Description))
+	fmt.Fprintf(&g.ops, "// %s %s 返回 %s %s\ntype %s struct {\n\tStatusCode int\n", typeName, opName, code, desc, typeName)
+	if bodyType != "" {
+		fmt.Fprintf(&g.ops, "\tBody       %s\n", bodyType)
+	}
+	g.ops.WriteString("\tRaw        []byte\n}\n\n")
+
+	constructor := "new" + typeName
+	fmt.Fprintf(&g.ops, "func %s(status int, body []byte) *%s {\n\te := &%s{StatusCode: status, Raw: body}\n", constructor, typeName, typeName)
+	if bodyType != "" {
+		g.ops.WriteString("\t_ = json.Unmarshal(body, &e.Body)\n")
+	}
+	g.ops.WriteString("\treturn e\n}\n\n")
+	fmt.Fprintf(&g.ops, "func (e *%s) Error() string {\n\treturn fmt.Sprintf(\"%s: status %%d\", e.StatusCode)\n}\n\n", typeName, label)
+	return constructor, nil
+}
+
+func isObject(s *schema) bool {
+	return s != nil && s.Ref == "" && (len(s.Properties) > 0 || len(s.AllOf) > 1)
+}
+
+func isSuccess(code string) bool {
+	return strings.HasPrefix(code, "2")
+}
+
+/**
+ * 响应码的排序：具体状态码、范围、default
+ */
+func statusOrder(code string) int {
+	if n, err := strconv.Atoi(code); err == nil {
+		return n
+	}
+	if len(code) == 3 && strings.HasSuffix(strings.ToUpper(code), "XX") {
+		return 1000 + int(code[0]-'0')
+	}
+	return 2000
+}
+
+func statusCondition(code string) string {
+	if _, err := strconv.Atoi(code); err == nil {
+		return "status == " + code
+	}
+	low := int(code[0]-'0') * 100
+	return fmt.Sprintf("status >= %d && status < %d", low, low+100)
+}
+
+func statusName(code string) string {
+	if code == "default" {
+		return "Default"
+	}
+	if n, err := strconv.Atoi(code); err == nil {
+		if text := http.StatusText(n); text != "" {
+			return goName(text)
+		}
+	}
+	return "Status" + strings.ToUpper(code)
+}
+
+/**
+ * 可选参数的非零值判断，返回空表示总是设置
+ */
+func nonZero(expr, typ string, structs map[string]bool) string {
+	switch {
+	case typ == "string":
+		return expr + ` != ""`
+	case typ == "bool":
+		return expr
+	case strings.HasPrefix(typ, "int") || strings.HasPrefix(typ, "float"):
+		return expr + " != 0"
+	case typ == "time.Time":
+		return "!" + expr + ".IsZero()"
+	case strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") || strings.HasPrefix(typ, "*") || typ == "any":
+		return expr + " != nil"
+	case structs[typ]:
+		return ""
+	}
+	return ""
+}
+
+/**
+ * 转换为导出的Go名称，如 pet_id -> PetID，getPetById -> GetPetByID
+ */
+func goName(s string) string {
+	words := []string{}
+	var word []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		// 小写转大写处分词，连续大写视为一个词
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	var b strings.Builder
+	for _, w := range words {
+		lower := strings.ToLower(w)
+		if initialisms[lower] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		b.WriteString(strings.ToUpper(string(r[0])) + string(r[1:]))
+	}
+	name := b.String()
+	if name == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "N" + name
+	}
+	return name
+}
+
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	used[unique] = true
+	return unique
+}
+
+func writeComment(buf *bytes.Buffer, name, description string) {
+	if line := firstLine(description); line != "" {
+		fmt.Fprintf(buf, "// %s %s\n", name, line)
+	}
+}
+
+func lineComment(description string) string {
+	if line := strings.TrimSpace(firstLine(description)); line != "" {
+		return " // " + line
+	}
+	return ""
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
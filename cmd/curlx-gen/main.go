@@ -0,0 +1,78 @@
+// curlx-gen 根据 OpenAPI 3 文档(JSON格式)生成调用curlx的Go客户端
+//
+//	curlx-gen -spec petstore.json -pkg petstore -o petstore/client_gen.go
+//
+// 生成的代码包含请求结构体、路径和查询参数编码、JSON响应解码以及每个状态码的错误类型
+// YAML格式的文档需要先转换为JSON，如 yq -o json petstore.yaml > petstore.json
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// 退出码
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("curlx-gen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	spec := fs.String("spec", "", "OpenAPI 3 document in JSON, - for stdin")
+	pkg := fs.String("pkg", "client", "package name of the generated code")
+	output := fs.String("o", "", "output file, stdout if empty")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *spec == "" {
+		fmt.Fprintln(stderr, "curlx-gen: -spec is required")
+		fs.Usage()
+		return exitUsage
+	}
+
+	var data []byte
+	var err error
+	if *spec == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(*spec)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "curlx-gen: %v\n", err)
+		return exitError
+	}
+
+	doc, err := parseSpec(data)
+	if err != nil {
+		fmt.Fprintf(stderr, "curlx-gen: %v\n", err)
+		return exitError
+	}
+	src, err := generate(doc, *pkg)
+	if err != nil {
+		fmt.Fprintf(stderr, "curlx-gen: %v\n", err)
+		return exitError
+	}
+
+	if *output == "" {
+		stdout.Write(src)
+		return exitOK
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		fmt.Fprintf(stderr, "curlx-gen: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
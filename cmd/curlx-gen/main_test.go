@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerateGolden(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "petstore.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := parseSpec(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate(doc, "petstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "petstore.golden")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("generated code differs from %s, run go test -update to refresh\n%s", golden, got)
+	}
+}
+
+// 注释和说明中出现包名时不应导入对应的包
+const commentSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Comments", "version": "1"},
+  "paths": {
+    "/items": {
+      "get": {
+        "operationId": "listItems",
+        "summary": "Returns time. and json. values",
+        "responses": {"204": {"description": "fmt. nothing"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Item": {
+        "type": "object",
+        "properties": {"updatedAt": {"type": "string", "description": "Last update time."}}
+      }
+    }
+  }
+}`
+
+// 在临时模块中 go vet 生成的代码，引用当前目录的curlx
+// 依赖无法下载(离线)时跳过，生成内容由 TestGenerateGolden 检查
+func TestGeneratedCodeCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go vet in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden, err := os.ReadFile(filepath.Join("testdata", "petstore.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := parseSpec([]byte(commentSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments, err := generate(doc, "comments")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	gomod := "module curlxgen\n\ngo 1.20\n\nrequire github.com/yuninks/curlx v0.0.0\n\nreplace github.com/yuninks/curlx => " + root + "\n"
+	files := map[string][]byte{
+		"go.mod":                 []byte(gomod),
+		"petstore/client_gen.go": golden,
+		"comments/client_gen.go": comments,
+	}
+	if sum, err := os.ReadFile(filepath.Join(root, "go.sum")); err == nil {
+		files["go.sum"] = sum
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goCmd := func(args ...string) *exec.Cmd {
+		cmd := exec.Command(goTool, args...)
+		cmd.Dir = dir
+		// 外层的 GOFLAGS(如 -modfile) 不适用于临时模块
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+		return cmd
+	}
+	if out, err := goCmd("mod", "tidy").CombinedOutput(); err != nil {
+		t.Skipf("dependencies unavailable: %v\n%s", err, out)
+	}
+	if out, err := goCmd("vet", "./...").CombinedOutput(); err != nil {
+		t.Fatalf("go vet generated code: %v\n%s", err, out)
+	}
+}
+
+func TestRun(t *testing.T) {
+	spec, err := os.ReadFile(filepath.Join("testdata", "petstore.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "client_gen.go")
+	stderr := &bytes.Buffer{}
+	if code := run([]string{"-spec", "-", "-pkg", "petstore", "-o", file}, bytes.NewReader(spec), io.Discard, stderr); code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	if b, _ := os.ReadFile(file); !bytes.Contains(b, []byte("package petstore")) {
+		t.Fatalf("file content = %s", b)
+	}
+
+	if code := run(nil, nil, io.Discard, io.Discard); code != exitUsage {
+		t.Fatalf("exit code = %d, want %d", code, exitUsage)
+	}
+
+	cases := map[string]string{
+		`{"openapi":"2.0"}`: "unsupported openapi version",
+		`{"openapi":"3.0.0","paths":{"/a":{"get":{"responses":{"200":{"$ref":"#/components/responses/Missing"}}}}}}`: "not found",
+		`openapi: 3.0.0`: "only JSON is supported",
+	}
+	for in, want := range cases {
+		stderr.Reset()
+		if code := run([]string{"-spec", "-"}, strings.NewReader(in), io.Discard, stderr); code != exitError {
+			t.Fatalf("%s: exit code = %d, want %d", in, code, exitError)
+		}
+		if !strings.Contains(stderr.String(), want) {
+			t.Fatalf("%s: stderr = %q, want %q", in, stderr, want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// openAPI OpenAPI 3 文档中生成代码需要的部分
+type openAPI struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       info                `json:"info"`
+	Paths      map[string]pathItem `json:"paths"`
+	Components components          `json:"components"`
+}
+
+type info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type components struct {
+	Schemas       map[string]*schema      `json:"schemas"`
+	Parameters    map[string]*parameter   `json:"parameters"`
+	RequestBodies map[string]*requestBody `json:"requestBodies"`
+	Responses     map[string]*response    `json:"responses"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `json:"parameters"`
+	Get        *operation   `json:"get"`
+	Put        *operation   `json:"put"`
+	Post       *operation   `json:"post"`
+	Delete     *operation   `json:"delete"`
+	Patch      *operation   `json:"patch"`
+	Head       *operation   `json:"head"`
+	Options    *operation   `json:"options"`
+}
+
+/**
+ * 按固定顺序返回路径下的操作
+ */
+func (p pathItem) operations() []struct {
+	method string
+	op     *operation
+} {
+	all := []struct {
+		method string
+		op     *operation
+	}{
+		{"GET", p.Get}, {"PUT", p.Put}, {"POST", p.Post}, {"DELETE", p.Delete},
+		{"PATCH", p.Patch}, {"HEAD", p.Head}, {"OPTIONS", p.Options},
+	}
+	ops := all[:0]
+	for _, o := range all {
+		if o.op != nil {
+			ops = append(ops, o)
+		}
+	}
+	return ops
+}
+
+type operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Deprecated  bool                 `json:"deprecated"`
+	Parameters  []*parameter         `json:"parameters"`
+	RequestBody *requestBody         `json:"requestBody"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path/query/header/cookie
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Ref      string               `json:"$ref"`
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Ref         string               `json:"$ref"`
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Items                *schema            `json:"items"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Enum                 []any              `json:"enum"`
+	AllOf                []*schema          `json:"allOf"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"` // bool 或 schema
+}
+
+/**
+ * additionalProperties 为schema时返回它，为true时返回空schema
+ */
+func (s *schema) additional() (*schema, bool) {
+	raw := strings.TrimSpace(string(s.AdditionalProperties))
+	switch raw {
+	case "", "false":
+		return nil, false
+	case "true", "{}":
+		return &schema{}, true
+	}
+	extra := &schema{}
+	if err := json.Unmarshal(s.AdditionalProperties, extra); err != nil {
+		return &schema{}, true
+	}
+	return extra, true
+}
+
+/**
+ * 解析文档，只支持JSON格式
+ */
+func parseSpec(data []byte) (*openAPI, error) {
+	doc := &openAPI{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("parse spec (only JSON is supported): %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q, want 3.x", doc.OpenAPI)
+	}
+	return doc, nil
+}
+
+/**
+ * 取出 #/components/<kind>/<name> 中的名称
+ */
+func refName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported $ref %q", ref)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+func (d *openAPI) parameter(p *parameter) (*parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := d.Components.Parameters[name]; ok {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("parameter %q not found", p.Ref)
+}
+
+func (d *openAPI) requestBody(b *requestBody) (*requestBody, error) {
+	if b.Ref == "" {
+		return b, nil
+	}
+	name, err := refName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := d.Components.RequestBodies[name]; ok {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("request body %q not found", b.Ref)
+}
+
+func (d *openAPI) response(r *response) (*response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	name, err := refName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := d.Components.Responses[name]; ok {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("response %q not found", r.Ref)
+}
+
+func (d *openAPI) schema(s *schema) (*schema, error) {
+	if s.Ref == "" {
+		return s, nil
+	}
+	name, err := refName(s.Ref, "schemas")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := d.Components.Schemas[name]; ok {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("schema %q not found", s.Ref)
+}
+
+/**
+ * 取出JSON格式的内容
+ */
+func jsonContent(content map[string]mediaType) (*schema, bool) {
+	for _, t := range []string{"application/json", "application/problem+json"} {
+		if m, ok := content[t]; ok && m.Schema != nil {
+			return m.Schema, true
+		}
+	}
+	for _, t := range sortedKeys(content) {
+		if m := content[t]; strings.HasSuffix(strings.SplitN(t, ";", 2)[0], "+json") && m.Schema != nil {
+			return m.Schema, true
+		}
+	}
+	return nil, false
+}
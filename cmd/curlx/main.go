@@ -0,0 +1,412 @@
+// curlx 命令行工具，参数与curl保持一致，底层使用curlx库发送请求
+//
+//	curlx -X POST --json '{"a":1}' --retry 3 -w '%{http_code} %{time_total}\n' --path data.id https://example.com/api
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/yuninks/curlx"
+)
+
+const usage = `Usage: curlx [options...] <url>
+
+Request options (same as curl):
+  -X, --request <method>        Request method
+  -H, --header <header>         Add request header
+  -d, --data <data>             Send data (--data-raw, --data-binary, --data-urlencode)
+      --json <data>             Send JSON data
+  -F, --form <name=content>     Multipart form field, name=@file for files
+  -b, --cookie <data>           Send cookies "a=1; b=2"
+  -u, --user <user:password>    Basic auth
+  -A, --user-agent <name>       User-Agent
+  -e, --referer <url>           Referer
+      --compressed              Request gzip compressed response
+  -x, --proxy <url>             Use proxy (http://, socks5://)
+  -k, --insecure                Skip TLS verification
+  -L, --location                Follow redirects
+  -m, --max-time <seconds>      Maximum time for the request
+      --retry <num>             Retry on transient errors
+      --retry-delay <seconds>   Wait between retries
+
+Output options:
+  -o, --output <file>           Write body to file instead of stdout
+  -i, --include                 Include response headers in output
+  -w, --write-out <format>      Print after completion, e.g. '%{http_code} %{time_total}\n'
+      --path <gjson path>       Print the value at path of the JSON response
+  -f, --fail                    Fail with exit code 22 on HTTP errors
+  -s, --silent                  Do not print errors
+  -S, --show-error              Print errors even with -s
+  -v, --verbose                 Print request logs to stderr
+
+TLS options:
+      --cacert <file>           CA certificate to verify peer against
+      --cert <file>             Client certificate file
+      --key <file>              Client private key file
+`
+
+// 退出码，与curl一致
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUsage     = 2
+	exitHTTPError = 22
+)
+
+type cliOptions struct {
+	output    string
+	include   bool
+	writeOut  string
+	path      string
+	fail      bool
+	silent    bool
+	showError bool
+	verbose   bool
+	cacert    string
+	cert      string
+	key       string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	opts, rest, err := parseCLIArgs(args)
+	if err != nil {
+		if errors.Is(err, errHelp) {
+			fmt.Fprint(stdout, usage)
+			return exitOK
+		}
+		fmt.Fprintf(stderr, "curlx: %v\n%s", err, usage)
+		return exitUsage
+	}
+
+	fail := func(code int, err error) int {
+		if !opts.silent || opts.showError {
+			fmt.Fprintf(stderr, "curlx: %v\n", err)
+		}
+		return code
+	}
+
+	// 参数由用户在终端输入，与curl一样允许读取本地文件
+	cc, err := curlx.ParseCurlArgs(rest, curlx.WithCurlFileAccess(""))
+	if err != nil {
+		return fail(exitUsage, err)
+	}
+
+	clientOpts := []curlx.Option{}
+	if opts.verbose {
+		clientOpts = append(clientOpts,
+			curlx.WithOptionLog(stderrLogger{log.New(stderr, "* ", 0)}),
+			curlx.WithOptionLogLevel(curlx.LevelDebug),
+		)
+	} else {
+		clientOpts = append(clientOpts, curlx.WithOptionStructuredLogger(discardLogger{}))
+	}
+	if opts.cacert != "" || opts.cert != "" {
+		tlsConfig, err := loadTLSConfig(opts)
+		if err != nil {
+			return fail(exitError, err)
+		}
+		clientOpts = append(clientOpts, curlx.WithOptionTLSConfig(tlsConfig))
+	}
+
+	client, err := cc.NewCurlx(clientOpts...)
+	if err != nil {
+		return fail(exitError, err)
+	}
+
+	resp := client.SendWithResponse(context.Background(), cc.Params...)
+	if err := resp.GetError(); err != nil {
+		return fail(exitError, err)
+	}
+	defer resp.Close()
+
+	body, err := resp.GetBody()
+	if err != nil {
+		return fail(exitError, err)
+	}
+
+	if opts.fail && resp.GetStatusCode() >= 400 {
+		return fail(exitHTTPError, fmt.Errorf("the requested URL returned error: %d", resp.GetStatusCode()))
+	}
+
+	if opts.path != "" {
+		body = []byte(gjson.GetBytes(body, opts.path).String() + "\n")
+	}
+
+	out := stdout
+	if opts.output != "" && opts.output != "-" {
+		f, err := os.Create(opts.output)
+		if err != nil {
+			return fail(exitError, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if opts.include {
+		writeHeaders(out, resp)
+	}
+	if _, err := out.Write(body); err != nil {
+		return fail(exitError, err)
+	}
+
+	if opts.writeOut != "" {
+		fmt.Fprint(stdout, writeOut(opts.writeOut, resp, len(body)))
+	}
+	return exitOK
+}
+
+var errHelp = errors.New("help requested")
+
+// 需要带参数的短选项，与 curlx.ParseCurlArgs 一致，另加命令行工具自身的 -o -w
+const shortArgFlags = "XHdFbuxAemow"
+
+// 不带参数的长选项，其余长选项的下一个参数都是它的值
+var longBoolFlags = map[string]bool{
+	"--help": true, "--include": true, "--fail": true, "--silent": true, "--show-error": true,
+	"--verbose": true, "--insecure": true, "--location": true, "--compressed": true, "--globoff": true,
+}
+
+/**
+ * 拆开组合的短选项，-fsSL 拆为 -f -s -S -L，-ofile 拆为 -o file
+ * 带参数的选项之后的内容都作为它的参数，如 -sXPOST 拆为 -s -X POST
+ * 选项的值原样保留，即使以 - 开头
+ */
+func expandShortFlags(args []string) []string {
+	expanded := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		takesValue := false
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			expanded = append(expanded, arg)
+			takesValue = !strings.Contains(arg, "=") && !longBoolFlags[arg]
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for j := 1; j < len(arg); j++ {
+				expanded = append(expanded, "-"+arg[j:j+1])
+				if strings.IndexByte(shortArgFlags, arg[j]) >= 0 {
+					if j+1 < len(arg) {
+						expanded = append(expanded, arg[j+1:])
+					} else {
+						takesValue = true
+					}
+					break
+				}
+			}
+		default:
+			expanded = append(expanded, arg)
+		}
+		if takesValue && i+1 < len(args) {
+			i++
+			expanded = append(expanded, args[i])
+		}
+	}
+	return expanded
+}
+
+/**
+ * 取出命令行工具自身的参数，其余交给 curlx.ParseCurlArgs
+ */
+func parseCLIArgs(args []string) (cliOptions, []string, error) {
+	opts := cliOptions{}
+	rest := []string{}
+
+	args = expandShortFlags(args)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := func() (string, error) {
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("option %s requires a value", arg)
+			}
+			i++
+			return args[i], nil
+		}
+
+		var err error
+		switch arg {
+		case "-h", "--help":
+			return opts, nil, errHelp
+		case "-o", "--output":
+			opts.output, err = value()
+		case "-w", "--write-out":
+			opts.writeOut, err = value()
+		case "--path":
+			opts.path, err = value()
+		case "--cacert":
+			opts.cacert, err = value()
+		case "--cert":
+			opts.cert, err = value()
+		case "--key":
+			opts.key, err = value()
+		case "-i", "--include":
+			opts.include = true
+		case "-f", "--fail":
+			opts.fail = true
+		case "-s", "--silent":
+			opts.silent = true
+		case "-S", "--show-error":
+			opts.showError = true
+		case "-v", "--verbose":
+			opts.verbose = true
+		default:
+			rest = append(rest, arg)
+		}
+		if err != nil {
+			return opts, nil, err
+		}
+	}
+	return opts, rest, nil
+}
+
+func loadTLSConfig(opts cliOptions) (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if opts.cacert != "" {
+		pem, err := os.ReadFile(opts.cacert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.cacert)
+		}
+		cfg.RootCAs = pool
+	}
+	if opts.cert != "" {
+		keyFile := opts.key
+		if keyFile == "" {
+			keyFile = opts.cert
+		}
+		cert, err := tls.LoadX509KeyPair(opts.cert, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func writeHeaders(w io.Writer, resp curlx.Response) {
+	r := resp.GetResponse()
+	fmt.Fprintf(w, "%s %s\r\n", r.Proto, r.Status)
+	headers := resp.GetHeaders()
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range headers[k] {
+			fmt.Fprintf(w, "%s: %s\r\n", k, v)
+		}
+	}
+	fmt.Fprint(w, "\r\n")
+}
+
+/**
+ * 按curl -w 的格式输出，时间均为从请求开始累计的秒数
+ */
+func writeOut(format string, resp curlx.Response, size int) string {
+	timing := resp.GetTiming()
+	connect := timing.DNSLookup + timing.TCPConnect
+	appConnect := time.Duration(0)
+	if timing.TLSHandshake > 0 {
+		appConnect = connect + timing.TLSHandshake
+	}
+
+	remoteIP, remotePort, _ := net.SplitHostPort(timing.RemoteAddr)
+	seconds := func(d time.Duration) string {
+		return strconv.FormatFloat(d.Seconds(), 'f', 6, 64)
+	}
+	effectiveURL := ""
+	if r := resp.GetResponse(); r != nil && r.Request != nil {
+		effectiveURL = r.Request.URL.String()
+	}
+	numConnects := "1"
+	if timing.ConnReused {
+		numConnects = "0"
+	}
+
+	vars := map[string]string{
+		"http_code":          strconv.Itoa(resp.GetStatusCode()),
+		"response_code":      strconv.Itoa(resp.GetStatusCode()),
+		"time_namelookup":    seconds(timing.DNSLookup),
+		"time_connect":       seconds(connect),
+		"time_appconnect":    seconds(appConnect),
+		"time_starttransfer": seconds(timing.FirstByte),
+		"time_total":         seconds(timing.Total),
+		"size_download":      strconv.Itoa(size),
+		"remote_ip":          remoteIP,
+		"remote_port":        remotePort,
+		"num_connects":       numConnects,
+		"url_effective":      effectiveURL,
+		"content_type":       resp.GetHeaderLine("Content-Type"),
+	}
+
+	b := strings.Builder{}
+	for i := 0; i < len(format); i++ {
+		switch {
+		case strings.HasPrefix(format[i:], "%{"):
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				b.WriteString(format[i:])
+				return b.String()
+			}
+			name := format[i+2 : i+end]
+			if v, ok := vars[name]; ok {
+				b.WriteString(v)
+			} else {
+				b.WriteString(format[i : i+end+1])
+			}
+			i += end
+		case format[i] == '\\' && i+1 < len(format):
+			i++
+			switch format[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(format[i])
+			}
+		default:
+			b.WriteByte(format[i])
+		}
+	}
+	return b.String()
+}
+
+// stderrLogger -v 时输出请求日志
+type stderrLogger struct {
+	logger *log.Logger
+}
+
+func (l stderrLogger) Infof(ctx context.Context, format string, args ...any) {
+	l.logger.Printf(format, args...)
+}
+
+func (l stderrLogger) Errorf(ctx context.Context, format string, args ...any) {
+	l.logger.Printf(format, args...)
+}
+
+// discardLogger 不输出日志
+type discardLogger struct{}
+
+func (discardLogger) Log(ctx context.Context, level curlx.Level, msg string, fields ...curlx.Field) {}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次返回503，验证重试
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"method":"` + r.Method + `","body":` + string(body) + `,"tenant":"` + r.Header.Get("X-Tenant") + `"}`))
+	}))
+	defer srv.Close()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{
+		"--json", `{"a":1}`, "-H", "X-Tenant: t1",
+		"--retry", "2", "--retry-delay", "0.01",
+		"--path", "body.a", "-w", `%{http_code}\n`,
+		srv.URL,
+	}, stdout, stderr)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	if got := stdout.String(); got != "1\n200\n" {
+		t.Fatalf("stdout = %q", got)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
+
+func TestRunOutputAndFail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "out.txt")
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if code := run([]string{"-o", file, "-w", "%{time_total}", srv.URL}, stdout, stderr); code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	if b, _ := os.ReadFile(file); string(b) != "hello" {
+		t.Fatalf("file content = %q", b)
+	}
+	if !regexp.MustCompile(`^\d+\.\d{6}$`).MatchString(stdout.String()) {
+		t.Fatalf("write-out = %q", stdout)
+	}
+
+	if code := run([]string{"-f", "-s", srv.URL + "/missing"}, io.Discard, stderr); code != exitHTTPError {
+		t.Fatalf("exit code = %d, want %d", code, exitHTTPError)
+	}
+	if code := run([]string{"--bogus", srv.URL}, io.Discard, io.Discard); code != exitUsage {
+		t.Fatalf("exit code = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestRunShortFlags(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		case "/final":
+			w.Write([]byte("final"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// -fsSL 跟随重定向
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if code := run([]string{"-fsSL", srv.URL + "/redirect"}, stdout, stderr); code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	if stdout.String() != "final" {
+		t.Fatalf("stdout = %q", stdout)
+	}
+
+	// 没有 -L 时返回302响应
+	stdout.Reset()
+	if code := run([]string{"-s", "-w", "%{http_code}", srv.URL + "/redirect"}, stdout, stderr); code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	if !bytes.HasSuffix(stdout.Bytes(), []byte("302")) {
+		t.Fatalf("stdout = %q", stdout)
+	}
+
+	// -fsS 出错时仍输出错误
+	stderr.Reset()
+	if code := run([]string{"-fsS", srv.URL + "/missing"}, io.Discard, stderr); code != exitHTTPError {
+		t.Fatalf("exit code = %d, want %d", code, exitHTTPError)
+	}
+	if !bytes.Contains(stderr.Bytes(), []byte("404")) {
+		t.Fatalf("stderr = %q", stderr)
+	}
+
+	// -sv 输出请求日志
+	stderr.Reset()
+	if code := run([]string{"-sv", "-L", srv.URL + "/redirect"}, io.Discard, stderr); code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	if stderr.Len() == 0 {
+		t.Fatal("expected verbose logs on stderr")
+	}
+
+	// -ofile 和 -w'fmt'
+	file := filepath.Join(t.TempDir(), "out.txt")
+	stdout.Reset()
+	if code := run([]string{"-Lo" + file, "-w%{http_code}", srv.URL + "/redirect"}, stdout, stderr); code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	if b, _ := os.ReadFile(file); string(b) != "final" {
+		t.Fatalf("file content = %q", b)
+	}
+	if stdout.String() != "200" {
+		t.Fatalf("write-out = %q", stdout)
+	}
+}
+
+func TestExpandShortFlags(t *testing.T) {
+	cases := map[string][]string{
+		"-fsSL":        {"-f", "-s", "-S", "-L"},
+		"-ofile":       {"-o", "file"},
+		"-sXPOST":      {"-s", "-X", "POST"},
+		"-so -x":       {"-s", "-o", "-x"},
+		"--data -abc":  {"--data", "-abc"},
+		"--fail -sk":   {"--fail", "-s", "-k"},
+		"--json={} -i": {"--json={}", "-i"},
+	}
+	for in, want := range cases {
+		got := expandShortFlags(strings.Fields(in))
+		if strings.Join(got, " ") != strings.Join(want, " ") || len(got) != len(want) {
+			t.Errorf("%s: got %q, want %q", in, got, want)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package curlx
 
 import "errors"
 
+// Version 当前版本
+const Version = "1.1.0"
+
 type UserAgent string
 
 const (
@@ -0,0 +1,732 @@
+package curlx
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.yun.ink/pkg/convx"
+)
+
+// CurlOption curl命令导出和解析的选项
+type CurlOption func(*curlOptions)
+
+type curlOptions struct {
+	redact     RedactRules
+	noRedact   bool
+	fileAccess bool
+	fileDir    string
+}
+
+func newCurlOptions(opts []CurlOption) curlOptions {
+	o := curlOptions{redact: DefaultRedactRules()}
+	for _, apply := range opts {
+		apply(&o)
+	}
+	return o
+}
+
+/**
+ * ToCurl 使用的脱敏规则，默认为 DefaultRedactRules
+ */
+func WithCurlRedact(rules RedactRules) CurlOption {
+	return func(o *curlOptions) {
+		o.redact = rules
+	}
+}
+
+/**
+ * ToCurl 输出原始的请求头、Cookie和URL，不做脱敏
+ * 输出内容包含认证信息，不要粘贴到工单或日志中
+ */
+func WithCurlNoRedact() CurlOption {
+	return func(o *curlOptions) {
+		o.noRedact = true
+	}
+}
+
+/**
+ * 解析时允许读取命令引用的本地文件(-d @file、--json @file、--data-urlencode name@file、-F name=@file、-F name=<file)
+ * 相对路径相对于dir，dir之外的文件(包括 .. 和绝对路径)不能读取，不处理符号链接
+ * dir为空时不限制，相对路径相对于当前工作目录，只应用于用户自己输入的命令
+ */
+func WithCurlFileAccess(dir string) CurlOption {
+	return func(o *curlOptions) {
+		o.fileAccess = true
+		o.fileDir = dir
+	}
+}
+
+// ErrCurlFileAccess curl命令引用了本地文件，但未通过 WithCurlFileAccess 允许
+var ErrCurlFileAccess = errors.New("curlx ParseCurl: reading local files is not allowed")
+
+/**
+ * 读取命令引用的本地文件
+ */
+func (o curlOptions) readFile(path string) ([]byte, error) {
+	if !o.fileAccess {
+		return nil, fmt.Errorf("%w: %s, use WithCurlFileAccess", ErrCurlFileAccess, path)
+	}
+	if o.fileDir == "" {
+		return os.ReadFile(path)
+	}
+	dir, err := filepath.Abs(o.fileDir)
+	if err != nil {
+		return nil, err
+	}
+	full := path
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(dir, full)
+	}
+	rel, err := filepath.Rel(dir, filepath.Clean(full))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%w: %s is outside %s", ErrCurlFileAccess, path, o.fileDir)
+	}
+	return os.ReadFile(full)
+}
+
+/**
+ * 导出为curl命令，方便在终端复现请求
+ * 默认按 DefaultRedactRules 脱敏请求头(Authorization等)、Cookie和URL中的参数及密码，
+ * 需要可直接执行的命令时使用 WithCurlNoRedact
+ * 文本字段使用 --form-string，值以@或<开头时不会被curl当作文件读取
+ * 表单文件只能输出文件名(-F name=@filename)，需要在执行目录下准备同名文件
+ */
+func (p ClientParams) ToCurl(opts ...CurlOption) string {
+	o := newCurlOptions(opts)
+	args := []string{"curl"}
+
+	method := string(p.Method)
+	if method == "" {
+		method = string(MethodGet)
+	}
+	args = append(args, "-X", method)
+
+	rawURL := p.Url
+	if u, err := expandPathParams(rawURL, p.PathParams); err == nil {
+		rawURL = u
+	}
+	if u, err := withQuery(rawURL, p.Query); err == nil {
+		rawURL = u
+	}
+	headers := http.Header{}
+	for k, v := range p.Headers {
+		headers[k] = append([]string{}, v...)
+	}
+
+	var bodyArgs []string
+	if len(p.Body) > 0 {
+		switch p.ContentType {
+		case ContentTypeForm:
+			params := []FormParam{}
+			if err := json.Unmarshal(p.Body, &params); err == nil {
+				for _, fp := range params {
+					if fp.FieldType == FieldTypeFile {
+						bodyArgs = append(bodyArgs, "-F", fp.FieldName+"=@"+fp.FileName)
+					} else {
+						bodyArgs = append(bodyArgs, "--form-string", fp.FieldName+"="+fp.FieldValue)
+					}
+				}
+			}
+			// 由curl生成boundary
+			headers.Del("Content-Type")
+		case ContentTypeUrlEncoded:
+			m := map[string]any{}
+			if err := json.Unmarshal(p.Body, &m); err == nil {
+				for _, k := range sortedKeys(m) {
+					bodyArgs = append(bodyArgs, "--data-urlencode", k+"="+convx.ToString(m[k]))
+				}
+			}
+		case ContentTypeJson, ContentTypeXml, ContentTypeText:
+			bodyArgs = append(bodyArgs, "--data-raw", string(p.Body))
+		default:
+			// GET请求的JSON参数拼接到URL上
+			m := map[string]any{}
+			if p.Method == MethodGet && json.Unmarshal(p.Body, &m) == nil {
+				if u, err := url.Parse(rawURL); err == nil {
+					query := u.Query()
+					for _, k := range sortedKeys(m) {
+						addQueryValue(query, k, reflect.ValueOf(m[k]))
+					}
+					u.RawQuery = query.Encode()
+					rawURL = u.String()
+				}
+			} else {
+				bodyArgs = append(bodyArgs, "--data-raw", string(p.Body))
+			}
+		}
+		if p.ContentType != "" && p.ContentType != ContentTypeForm && headers.Get("Content-Type") == "" {
+			headers.Set("Content-Type", string(p.ContentType))
+		}
+	}
+
+	cookies := make([]string, 0, len(p.Cookies))
+	if o.noRedact {
+		for _, c := range p.Cookies {
+			cookies = append(cookies, c.Name+"="+c.Value)
+		}
+	} else {
+		headers = o.redact.RedactHeaders(headers)
+		cookies = o.redact.RedactCookies(p.Cookies)
+		rawURL = o.redact.RedactURL(rawURL)
+	}
+
+	for _, k := range sortedKeys(headers) {
+		for _, v := range headers[k] {
+			args = append(args, "-H", k+": "+v)
+		}
+	}
+
+	if len(cookies) > 0 {
+		args = append(args, "-b", strings.Join(cookies, "; "))
+	}
+
+	args = append(args, bodyArgs...)
+	args = append(args, rawURL)
+
+	for i := 1; i < len(args); i++ {
+		args[i] = shellQuote(args[i])
+	}
+	return strings.Join(args, " ")
+}
+
+/**
+ * shell单引号转义
+ */
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@,+%", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// CurlCommand 解析后的curl命令
+type CurlCommand struct {
+	Params   []Param       // 请求参数
+	Insecure bool          // -k 不校验HTTPS证书
+	Proxy    string        // -x/--proxy 代理地址
+	Timeout  time.Duration // -m/--max-time 超时时间
+	Location bool          // -L 跟随重定向
+}
+
+/**
+ * 客户端选项(-k/-m/-L)
+ * 与curl一致，没有 -L 时不跟随重定向
+ */
+func (cc *CurlCommand) Options() []Option {
+	opts := []Option{}
+	if !cc.Location {
+		opts = append(opts, WithOptionNoRedirect())
+	}
+	if cc.Insecure {
+		opts = append(opts, WithOptionTLSInsecureSkipVerify())
+	}
+	if cc.Timeout > 0 {
+		opts = append(opts, WithOptionTimeOut(cc.Timeout))
+	}
+	return opts
+}
+
+/**
+ * 按curl命令中的客户端参数(-k/-m/-L/--proxy)创建Curlx
+ */
+func (cc *CurlCommand) NewCurlx(opts ...Option) (*Curlx, error) {
+	c := NewCurlx(append(cc.Options(), opts...)...)
+	if cc.Proxy == "" {
+		return c, nil
+	}
+	proxyURL := cc.Proxy
+	if !strings.Contains(proxyURL, "://") {
+		proxyURL = "http://" + proxyURL
+	}
+	if strings.HasPrefix(proxyURL, "socks5://") || strings.HasPrefix(proxyURL, "socks5h://") {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		return c, c.WithProxySocks5(u.Host)
+	}
+	return c, c.WithProxyHttp(proxyURL)
+}
+
+/**
+ * 解析curl命令为请求参数
+ * 客户端级别的 -k/-m/-L/--proxy 不包含在返回值中，需要时使用 ParseCurlCommand
+ */
+func ParseCurl(cmd string, opts ...CurlOption) ([]Param, error) {
+	cc, err := ParseCurlCommand(cmd, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return cc.Params, nil
+}
+
+// 不影响请求内容的参数，直接忽略
+var curlIgnoredFlags = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true,
+	"-v": true, "--verbose": true,
+	"-i": true, "--include": true, "-g": true, "--globoff": true,
+}
+
+// 需要带参数的短选项
+var curlShortArgFlags = "XHdFbuxAem"
+
+/**
+ * 解析curl命令
+ * 支持 -X -H -d/--data-raw/--data-binary --data-urlencode --json -F/--form-string -b -u -k -m -L
+ * --compressed -x/--proxy --retry --retry-delay
+ * 命令可能来自接口文档等外部来源，默认不读取本地文件，引用文件时返回 ErrCurlFileAccess，
+ * 需要时使用 WithCurlFileAccess 允许
+ */
+func ParseCurlCommand(cmd string, opts ...CurlOption) (*CurlCommand, error) {
+	args, err := splitShellArgs(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && (args[0] == "curl" || filepath.Base(args[0]) == "curl") {
+		args = args[1:]
+	}
+	return ParseCurlArgs(args, opts...)
+}
+
+/**
+ * 解析已拆分的curl参数(不含curl本身)
+ * -u 必须包含密码(user:password)，curl会交互式输入密码，这里无法支持
+ */
+func ParseCurlArgs(args []string, opts ...CurlOption) (*CurlCommand, error) {
+	o := newCurlOptions(opts)
+	args = append([]string{}, args...)
+	var err error
+
+	var (
+		cc       = &CurlCommand{}
+		method   string
+		rawURL   string
+		headers  = http.Header{}
+		cookies  []http.Cookie
+		data     []string
+		form     []Param
+		hasForm  bool
+		dataSeen bool
+		isJSON   bool
+		retry    RetryPolicy
+	)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		flag, value, hasValue := arg, "", false
+
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			if k, v, ok := strings.Cut(arg, "="); ok {
+				flag, value, hasValue = k, v, true
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 2:
+			if strings.ContainsRune(curlShortArgFlags, rune(arg[1])) {
+				// -XPOST / -H'a: b'
+				flag, value, hasValue = arg[:2], arg[2:], true
+			} else {
+				// -sk / -sXPOST 等选项组合，带参数的选项及其后内容作为一个参数
+				expanded := []string{}
+				for j := 1; j < len(arg); j++ {
+					if strings.ContainsRune(curlShortArgFlags, rune(arg[j])) {
+						expanded = append(expanded, "-"+arg[j:])
+						break
+					}
+					expanded = append(expanded, "-"+arg[j:j+1])
+				}
+				args = append(args[:i+1], append(expanded, args[i+1:]...)...)
+				continue
+			}
+		case !strings.HasPrefix(arg, "-") || arg == "-":
+			if rawURL != "" {
+				return nil, fmt.Errorf("curlx ParseCurl: multiple urls: %s %s", rawURL, arg)
+			}
+			rawURL = arg
+			continue
+		}
+
+		if curlIgnoredFlags[flag] {
+			continue
+		}
+
+		needValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("curlx ParseCurl: option %s requires a value", flag)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch flag {
+		case "-k", "--insecure":
+			cc.Insecure = true
+		case "-L", "--location":
+			cc.Location = true
+		case "--compressed":
+			headers.Set("Accept-Encoding", "gzip")
+		case "-X", "--request":
+			if method, err = needValue(); err != nil {
+				return nil, err
+			}
+			method = strings.ToUpper(method)
+		case "--url":
+			if rawURL, err = needValue(); err != nil {
+				return nil, err
+			}
+		case "-H", "--header":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			name, val, ok := strings.Cut(v, ":")
+			if !ok {
+				return nil, fmt.Errorf("curlx ParseCurl: invalid header: %s", v)
+			}
+			headers.Add(strings.TrimSpace(name), strings.TrimSpace(val))
+		case "-A", "--user-agent":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			headers.Set("User-Agent", v)
+		case "-e", "--referer":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			headers.Set("Referer", v)
+		case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			if flag != "--data-raw" && strings.HasPrefix(v, "@") {
+				b, err := o.readFile(v[1:])
+				if err != nil {
+					return nil, err
+				}
+				v = string(b)
+				if flag != "--data-binary" {
+					v = strings.NewReplacer("\r", "", "\n", "").Replace(v)
+				}
+			}
+			data = append(data, v)
+			dataSeen = true
+		case "--json":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasPrefix(v, "@") {
+				b, err := o.readFile(v[1:])
+				if err != nil {
+					return nil, err
+				}
+				v = string(b)
+			}
+			data = append(data, v)
+			dataSeen = true
+			isJSON = true
+		case "--retry":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			if retry.Count, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("curlx ParseCurl: invalid --retry: %s", v)
+			}
+		case "--retry-delay":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			if retry.Delay, err = parseCurlSeconds(v); err != nil {
+				return nil, err
+			}
+		case "-m", "--max-time":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			if cc.Timeout, err = parseCurlSeconds(v); err != nil {
+				return nil, err
+			}
+		case "--data-urlencode":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			encoded, err := curlDataURLEncode(v, o)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, encoded)
+			dataSeen = true
+		case "-F", "--form":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			param, err := curlFormParam(v, o)
+			if err != nil {
+				return nil, err
+			}
+			form = append(form, param)
+			hasForm = true
+		case "--form-string":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			name, content, ok := strings.Cut(v, "=")
+			if !ok {
+				return nil, fmt.Errorf("curlx ParseCurl: invalid form field: %s", v)
+			}
+			form = append(form, SetParamsFormText(name, content))
+			hasForm = true
+		case "-b", "--cookie":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			if !strings.Contains(v, "=") {
+				return nil, fmt.Errorf("curlx ParseCurl: cookie file is not supported: %s", v)
+			}
+			for _, part := range strings.Split(v, ";") {
+				name, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+				if name != "" {
+					cookies = append(cookies, http.Cookie{Name: name, Value: val})
+				}
+			}
+		case "-u", "--user":
+			v, err := needValue()
+			if err != nil {
+				return nil, err
+			}
+			if !strings.Contains(v, ":") {
+				return nil, fmt.Errorf("curlx ParseCurl: %s %s has no password, use user:password", flag, v)
+			}
+			headers.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(v)))
+		case "-x", "--proxy":
+			if cc.Proxy, err = needValue(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("curlx ParseCurl: unsupported option %s", flag)
+		}
+	}
+
+	if rawURL == "" {
+		return nil, errors.New("curlx ParseCurl: no url specified")
+	}
+	if hasForm && dataSeen {
+		return nil, errors.New("curlx ParseCurl: -F cannot be combined with -d")
+	}
+
+	if method == "" {
+		method = string(MethodGet)
+		if hasForm || dataSeen {
+			method = string(MethodPost)
+		}
+	}
+
+	params := []Param{
+		SetParamsUrl(rawURL),
+		SetParamsMethod(Method(method)),
+	}
+
+	switch {
+	case hasForm:
+		headers.Del("Content-Type")
+		params = append(params, SetParamsContentType(ContentTypeForm))
+		params = append(params, form...)
+	case dataSeen:
+		body := strings.Join(data, "&")
+		contentType := headers.Get("Content-Type")
+		mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+		switch {
+		case isJSON && contentType == "":
+			// --json 同时设置 Content-Type 和 Accept
+			if headers.Get("Accept") == "" {
+				headers.Set("Accept", string(ContentTypeJson))
+			}
+			params = append(params, SetParamsContentType(ContentTypeJson))
+		case contentType == "":
+			// 与curl一致，默认按表单编码发送，Body原样传递
+			headers.Set("Content-Type", string(ContentTypeUrlEncoded))
+			params = append(params, SetParamsContentType(ContentTypeText))
+		case isJSONMediaType(mediaType):
+			params = append(params, SetParamsContentType(ContentTypeJson))
+		case mediaType == string(ContentTypeXml):
+			params = append(params, SetParamsContentType(ContentTypeXml))
+		default:
+			// 其他类型原样发送，Content-Type以请求头为准
+			params = append(params, SetParamsContentType(ContentTypeText))
+		}
+		params = append(params, SetParamsBody([]byte(body)))
+	}
+
+	for _, k := range sortedKeys(headers) {
+		for _, v := range headers[k] {
+			params = append(params, SetParamsHeader(k, v))
+		}
+	}
+	if len(cookies) > 0 {
+		params = append(params, SetCookies(cookies))
+	}
+	if retry.Count > 0 {
+		params = append(params, SetParamsRetry(retry.Count, retry.Delay))
+	}
+
+	cc.Params = params
+	return cc, nil
+}
+
+/**
+ * 解析秒数，支持小数
+ */
+func parseCurlSeconds(v string) (time.Duration, error) {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("curlx ParseCurl: invalid seconds: %s", v)
+	}
+	return time.Duration(f * float64(time.Second)), nil
+}
+
+/**
+ * --data-urlencode 的几种格式
+ * content / =content / name=content / @file / name@file
+ */
+func curlDataURLEncode(v string, o curlOptions) (string, error) {
+	if name, content, ok := strings.Cut(v, "="); ok {
+		if name == "" {
+			return url.QueryEscape(content), nil
+		}
+		return name + "=" + url.QueryEscape(content), nil
+	}
+	if name, file, ok := strings.Cut(v, "@"); ok {
+		b, err := o.readFile(file)
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			return url.QueryEscape(string(b)), nil
+		}
+		return name + "=" + url.QueryEscape(string(b)), nil
+	}
+	return url.QueryEscape(v), nil
+}
+
+/**
+ * -F name=value / name=@file[;type=...;filename=...] / name=<file
+ */
+func curlFormParam(v string, o curlOptions) (Param, error) {
+	name, content, ok := strings.Cut(v, "=")
+	if !ok {
+		return nil, fmt.Errorf("curlx ParseCurl: invalid form field: %s", v)
+	}
+
+	switch {
+	case strings.HasPrefix(content, "@"):
+		parts := strings.Split(content[1:], ";")
+		path := parts[0]
+		fileName := filepath.Base(path)
+		for _, attr := range parts[1:] {
+			if k, val, ok := strings.Cut(attr, "="); ok && strings.TrimSpace(k) == "filename" {
+				fileName = strings.Trim(val, `"`)
+			}
+		}
+		b, err := o.readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return SetParamsFormFile(name, fileName, b), nil
+	case strings.HasPrefix(content, "<"):
+		b, err := o.readFile(strings.Split(content[1:], ";")[0])
+		if err != nil {
+			return nil, err
+		}
+		return SetParamsFormText(name, string(b)), nil
+	}
+	return SetParamsFormText(name, content), nil
+}
+
+/**
+ * 按shell规则拆分命令行参数，支持单双引号、反斜杠转义和续行
+ */
+func splitShellArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		cur     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+			// 续行
+			if r == '\n' {
+				continue
+			}
+			// 双引号内只转义特定字符
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				cur.WriteRune('\\')
+			}
+			cur.WriteRune(r)
+			inArg = true
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("curlx ParseCurl: unterminated quote")
+	}
+	if escaped {
+		return nil, errors.New("curlx ParseCurl: trailing backslash")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
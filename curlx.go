@@ -2,11 +2,14 @@ package curlx
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"errors"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"golang.org/x/net/proxy"
@@ -20,8 +23,13 @@ import (
 // type DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
 
 type Curlx struct {
-	opts      ClientOptions
-	transport *http.Transport
+	opts         ClientOptions
+	transport    *http.Transport   // 底层传输，自定义传输不是 *http.Transport 时为nil
+	roundTripper http.RoundTripper // 包装中间件后的传输
+	stats        *connStats
+	logger       StructuredLogger
+	dedup        *dedupGroup
+	limiter      *rateLimiter // 客户端总限速
 }
 
 func NewCurlx(opts ...Option) *Curlx {
@@ -30,23 +38,30 @@ func NewCurlx(opts ...Option) *Curlx {
 		apply(&defaultOpts)
 	}
 
-	transport := &http.Transport{
-		DisableKeepAlives:     false, // 启用keep-alive连接复用
-		MaxIdleConns:          defaultOpts.MaxIdleConns,
-		MaxIdleConnsPerHost:   defaultOpts.MaxIdleConnsPerHost,
-		MaxConnsPerHost:       defaultOpts.MaxConnsPerHost,
-		IdleConnTimeout:       defaultOpts.IdleConnTimeout,
-		ExpectContinueTimeout: 1 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
+	stats := newConnStats()
+	roundTripper, transport := baseTransport(defaultOpts, stats)
+
+	defaultOpts.Redact = defaultOpts.Redact.withCredentials(defaultOpts.Auth)
+
+	// 未设置结构化日志时适配到 OptionLogger
+	logger := defaultOpts.StructuredLogger
+	if logger == nil {
+		logger = printfLogger{logger: defaultOpts.Logger}
 	}
 
-	if defaultOpts.InsecureSkipVerify {
-		transport.TLSClientConfig.InsecureSkipVerify = true
+	roundTripper = chainMiddlewares(roundTripper, defaultOpts.Middlewares)
+	if defaultOpts.Cache != nil {
+		roundTripper = newCacheTransport(roundTripper, defaultOpts.Cache)
 	}
 
 	return &Curlx{
-		opts:      defaultOpts,
-		transport: transport,
+		opts:         defaultOpts,
+		transport:    transport,
+		roundTripper: roundTripper,
+		stats:        stats,
+		logger:       logger,
+		dedup:        newDedupGroup(),
+		limiter:      newRateLimiter(defaultOpts.RateLimit),
 	}
 
 }
@@ -56,6 +71,9 @@ func NewCurlx(opts ...Option) *Curlx {
  * @param address "socks5://127.0.0.1:1080"
  */
 func (c *Curlx) WithProxySocks5(address string) error {
+	if c.transport == nil {
+		return ErrTransportNotConfigurable
+	}
 	baseDialer := &net.Dialer{
 		// Timeout:   180 * time.Second,
 		// KeepAlive: 180 * time.Second,
@@ -66,14 +84,14 @@ func (c *Curlx) WithProxySocks5(address string) error {
 	}
 	dialSocksProxy, err := proxy.SOCKS5("tcp", address, nil, baseDialer)
 	if err != nil {
-		c.opts.Logger.Errorf(context.Background(), "proxy.SOCKS5 err: %v", err)
+		c.log(context.Background(), LevelError, "curlx proxy.SOCKS5 failed", Field{Key: "error", Value: err})
 		return err
 	}
 	dialContext := (baseDialer).DialContext
 	if contextDialer, ok := dialSocksProxy.(proxy.ContextDialer); ok {
 		dialContext = contextDialer.DialContext
 	}
-	c.transport.DialContext = dialContext
+	c.transport.DialContext = c.stats.wrapDialContext(dialContext)
 	return nil
 }
 
@@ -82,9 +100,12 @@ func (c *Curlx) WithProxySocks5(address string) error {
  * @param proxyAddr "https://proxyserver:port"
  */
 func (c *Curlx) WithProxyHttp(proxyAddr string) error {
+	if c.transport == nil {
+		return ErrTransportNotConfigurable
+	}
 	proxy, err := url.Parse(proxyAddr)
 	if err != nil {
-		c.opts.Logger.Errorf(context.Background(), "proxy.HTTP/HTTPS err: %v", err)
+		c.log(context.Background(), LevelError, "curlx proxy.HTTP/HTTPS failed", Field{Key: "error", Value: err})
 		return err
 	}
 	c.transport.Proxy = http.ProxyURL(proxy)
@@ -94,16 +115,21 @@ func (c *Curlx) WithProxyHttp(proxyAddr string) error {
 // 指定访问的IP
 // 127.0.0.1:8080
 func (c *Curlx) WithAddress(ctx context.Context, addr string) {
+	if c.transport == nil {
+		c.log(ctx, LevelError, "curlx WithAddress failed", Field{Key: "error", Value: ErrTransportNotConfigurable})
+		return
+	}
 	// network tcp/udp
-	c.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+	c.transport.DialContext = c.stats.wrapDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
 		return net.Dial(network, addr)
-	}
+	})
 }
 
 /**
  * 简单请求
  */
 func (c *Curlx) Send(ctx context.Context, p ...Param) (res []byte, err error) {
+	ctx = ensureRequestID(ctx)
 	resp := c.exec(ctx, p...)
 	if resp.err != nil {
 		return nil, resp.err
@@ -112,22 +138,21 @@ func (c *Curlx) Send(ctx context.Context, p ...Param) (res []byte, err error) {
 
 	status := resp.GetStatusCode()
 	if status != 200 {
-		c.opts.Logger.Errorf(ctx, "curlx.Send status not OK: %d", status)
+		c.log(ctx, LevelError, "curlx.Send status not OK", Field{Key: "status", Value: status})
 		return nil, ErrStatusNotOK
 	}
 
 	body, err := resp.GetBody()
 	if err != nil {
-		c.opts.Logger.Errorf(ctx, "curlx.Send getBody err:%v", err)
+		c.log(ctx, LevelError, "curlx.Send getBody failed", Field{Key: "error", Value: err})
 		return nil, err
 	}
 
-	// 打印日志时截取前指定长度，避免日志过大
-	bodyLog := []rune(string(body))
-	if len(bodyLog) > c.opts.LoggerLength {
-		bodyLog = bodyLog[:c.opts.LoggerLength]
+	if c.logEnabled(LevelDebug) {
+		// 打印日志时截取前指定长度，避免日志过大
+		bodyLog := c.opts.formatBodyLog(body, resp.GetHeaderLine("Content-Type"), c.opts.responseBodyLogLength())
+		c.log(ctx, LevelDebug, "curlx.Send response body", Field{Key: "body", Value: bodyLog})
 	}
-	c.opts.Logger.Infof(ctx, "curlx.Send response body:%s", string(bodyLog))
 	return body, nil
 }
 
@@ -158,45 +183,107 @@ func (c *Curlx) SendWithResponse(ctx context.Context, ps ...Param) Response {
  * 注意：外部使用需要加这一句 defer response.Body.Close()
  */
 func (c *Curlx) exec(ctx context.Context, ps ...Param) Response {
-	resp := Response{}
-
-	client := &http.Client{
-		Timeout:   c.opts.TimeOut, // 整个请求的超时时间 设置该条连接的超时
-		Transport: c.transport,    //
+	p := c.newParams(ps...)
+
+	ctx = ensureRequestID(ctx)
+
+	// 合并相同的进行中请求
+	if c.opts.DedupKey != nil {
+		// 参数无法解析时不合并，由 execOnce 返回错误
+		if dp, err := c.dedupParams(p); err == nil {
+			if key := c.opts.DedupKey(dp); key != "" {
+				return c.dedup.do(ctx, key, func(ctx context.Context) Response {
+					return c.execRetry(ctx, p)
+				})
+			}
+		}
 	}
 
-	// 在http.Client中添加CheckRedirect函数 实现重定向控制
-	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-		if len(via) >= 10 { // 限制重定向次数
-			return errors.New("stopped after 10 redirects")
+	return c.execRetry(ctx, p)
+}
+
+/**
+ * 按重试策略发送，每次重试使用参数的副本重新构建请求
+ */
+func (c *Curlx) execRetry(ctx context.Context, p ClientParams) Response {
+	for attempt := 0; ; attempt++ {
+		resp := c.execOnce(ctx, p.clone())
+		if attempt >= p.Retry.Count || !p.Retry.shouldRetry(resp) {
+			return resp
+		}
+		resp.Close()
+
+		delay := p.Retry.delay(attempt, resp)
+		c.log(ctx, LevelWarn, "curlx.sendExec retry",
+			Field{Key: "attempt", Value: attempt + 1},
+			Field{Key: "status", Value: resp.GetStatusCode()},
+			Field{Key: "error", Value: resp.err},
+			Field{Key: "delay", Value: delay},
+		)
+		if c.opts.Metrics != nil && resp.request != nil {
+			c.opts.Metrics.RequestRetried(resp.request.URL.Host, resp.request.Method)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Response{err: ctx.Err(), requestID: resp.requestID}
+		case <-timer.C:
 		}
-		return nil
 	}
+}
+
+/**
+ * 发送一次请求
+ */
+func (c *Curlx) execOnce(ctx context.Context, p ClientParams) Response {
+	resp := Response{}
 
-	p := defaultParams()
-	for _, param := range ps {
-		param(&p)
+	client := c.httpClient()
+	if p.noTimeout {
+		client.Timeout = 0
 	}
 
-	// 截取Body前指定长度输出，避免日志过大
-	bodyLog := []rune(string(p.Body))
-	if len(bodyLog) > c.opts.LoggerLength {
-		bodyLog = bodyLog[:c.opts.LoggerLength]
+	// 请求的认证优先于客户端的认证
+	auth := p.Auth
+	if auth == nil {
+		auth = c.opts.Auth
 	}
+	redact := c.opts.Redact.withCredentials(auth)
 
-	c.opts.Logger.Infof(ctx, "curlx.sendExec params url:%s method:%s contentType:%s body:%s headers:%+v cookies:%+v", p.Url, p.Method, p.ContentType, string(bodyLog), p.Headers, p.Cookies)
+	resp.requestID = RequestIDFromContext(ctx)
+
+	if c.logEnabled(LevelDebug) {
+		// 截取Body前指定长度输出，避免日志过大
+		var bodyLog string
+		if p.ContentType == ContentTypeForm {
+			bodyLog = c.opts.formatFormLog(p.Body, c.opts.requestBodyLogLength())
+		} else {
+			bodyLog = c.opts.formatBodyLog(p.Body, string(p.ContentType), c.opts.requestBodyLogLength())
+		}
+
+		c.log(ctx, LevelDebug, "curlx.sendExec params",
+			Field{Key: "url", Value: redact.RedactURL(p.Url)},
+			Field{Key: "method", Value: p.Method},
+			Field{Key: "content_type", Value: p.ContentType},
+			Field{Key: "body", Value: bodyLog},
+			Field{Key: "headers", Value: redact.RedactHeaders(p.Headers)},
+			Field{Key: "cookies", Value: redact.RedactCookies(p.Cookies)},
+		)
+	}
 
 	err := p.parseMethod()
 	if err != nil {
-		c.opts.Logger.Errorf(ctx, "curlx.sendExec parseMethod err:%v", err)
+		c.log(ctx, LevelError, "curlx.sendExec parseMethod failed", Field{Key: "error", Value: err})
 		resp.err = err
 		return resp
 	}
 
 	// 判断和处理url
-	err = p.parseUrl()
+	err = p.parseUrl(c.opts.BaseURL)
 	if err != nil {
-		c.opts.Logger.Errorf(ctx, "curlx.sendExec parseUrl err:%v", err)
+		c.log(ctx, LevelError, "curlx.sendExec parseUrl failed", Field{Key: "error", Value: err})
 		resp.err = err
 		return resp
 	}
@@ -204,7 +291,7 @@ func (c *Curlx) exec(ctx context.Context, ps ...Param) Response {
 	// 处理参数
 	reqParams, err := p.parseParams()
 	if err != nil {
-		c.opts.Logger.Errorf(ctx, "curlx.sendExec parseParams err:%v", err)
+		c.log(ctx, LevelError, "curlx.sendExec parseParams failed", Field{Key: "error", Value: err})
 		resp.err = err
 		return resp
 	}
@@ -216,33 +303,159 @@ func (c *Curlx) exec(ctx context.Context, ps ...Param) Response {
 		reqParams,
 	)
 	if err != nil {
-		c.opts.Logger.Errorf(ctx, "curlx.sendExec NewRequest err:%v", err)
+		c.log(ctx, LevelError, "curlx.sendExec NewRequest failed", Field{Key: "error", Value: err})
 		resp.err = err
 		return resp
 	}
 
-	c.opts.Logger.Infof(ctx, "curlx.sendExec request:%+v", request)
 	resp.request = request
 
+	// 读取Body时校验响应签名
+	resp.verifier = p.Verifier
+	if resp.verifier == nil {
+		resp.verifier = c.opts.Verifier
+	}
+
 	// 这里指定要访问的HOST,到时候服务器获取主机是获取到这个
 	// request.Host = "api.hk.blueoceantech.co"
 
+	// 链路追踪
+	var span Span
+	if c.opts.Tracer != nil {
+		ctx, span = c.opts.Tracer.Start(ctx, "HTTP "+request.Method)
+		span.SetAttribute(AttrHTTPMethod, request.Method)
+		span.SetAttribute(AttrURLFull, redact.RedactURL(request.URL.String()))
+		span.SetAttribute(AttrServerAddress, request.URL.Hostname())
+	}
+
+	// 连接统计，响应Body关闭时结束
+	ctx, done := c.stats.begin(ctx, request.URL.Host)
+
+	// 记录请求各阶段耗时
+	timing := newRequestTiming()
+	ctx = timing.withTrace(ctx)
+	resp.timing = timing
+
+	// 缓存层写入缓存状态
+	cacheStatus := new(CacheStatus)
+	if c.opts.Cache != nil {
+		ctx = withCacheStatus(ctx, cacheStatus)
+		ctx = withCacheCredentials(ctx, redact)
+	}
+
 	// 设置上下文控制
 	request = request.WithContext(ctx)
 
 	// 处理请求头
-	p.parseHeaders(request)
+	p.parseHeaders(request, c.opts.UserAgent)
 
 	// 处理Cookies
 	p.parseCookies(request)
 
+	// 注入链路信息请求头
+	if c.opts.Propagator != nil {
+		c.opts.Propagator.Inject(ctx, request.Header)
+	}
+
+	// 请求ID请求头
+	if c.opts.RequestIDHeader != "" && request.Header.Get(c.opts.RequestIDHeader) == "" {
+		request.Header.Set(c.opts.RequestIDHeader, resp.requestID)
+	}
+
+	// 指标采集
+	metrics := c.opts.Metrics
+	if metrics != nil {
+		metrics.RequestStarted(request.URL.Host, request.Method)
+	}
+	requestSize := request.ContentLength
+	if requestSize < 0 {
+		requestSize = 0
+	}
+	finish := func(statusCode int, responseSize int64, err error) {
+		done()
+		timing.finish()
+		if metrics != nil {
+			metrics.RequestFinished(RequestMetrics{
+				Host:         request.URL.Host,
+				Method:       request.Method,
+				StatusCode:   statusCode,
+				Duration:     timing.snapshot().Total,
+				RequestSize:  requestSize,
+				ResponseSize: responseSize,
+				Err:          err,
+			})
+		}
+		if span != nil {
+			if statusCode > 0 {
+				span.SetAttribute(AttrHTTPStatusCode, statusCode)
+			}
+			if err != nil {
+				span.SetAttribute(AttrErrorType, fmt.Sprintf("%T", err))
+			} else if statusCode >= 400 {
+				span.SetAttribute(AttrErrorType, strconv.Itoa(statusCode))
+			}
+			span.End(err)
+		}
+	}
+
+	// 请求签名，在包装Body前计算摘要
+	signer := p.Signer
+	if signer == nil {
+		signer = c.opts.Signer
+	}
+	var bodyHash string
+	if signer != nil {
+		if bodyHash, err = payloadHash(request); err != nil {
+			c.log(ctx, LevelError, "curlx.sendExec sign failed", Field{Key: "error", Value: err})
+			finish(0, 0, err)
+			resp.err = err
+			return resp
+		}
+	}
+
+	// 认证和签名作用于发送的请求副本，签名在认证之后
+	if auth != nil || signer != nil {
+		client.Transport = &authTransport{
+			next:        client.Transport,
+			auth:        auth,
+			signer:      signer,
+			payloadHash: bodyHash,
+			host:        request.URL.Host,
+		}
+	}
+
+	// 上传限速和进度
+	limiter := newRateLimiter(p.RateLimit)
+	c.wrapRequestBody(request, p, limiter)
+
 	// 发起请求
 	response, err := client.Do(request)
 	if err != nil {
-		c.opts.Logger.Errorf(ctx, "curlx.sendExec client.Do err:%v", err)
+		c.log(ctx, LevelError, "curlx.sendExec client.Do failed",
+			Field{Key: "method", Value: request.Method},
+			Field{Key: "url", Value: redact.RedactURL(request.URL.String())},
+			Field{Key: "error", Value: err},
+		)
+		finish(0, 0, err)
 		resp.err = err
 		return resp
 	}
+	resp.cacheStatus = *cacheStatus
+	c.log(ctx, LevelInfo, "curlx.sendExec response",
+		Field{Key: "method", Value: request.Method},
+		Field{Key: "url", Value: redact.RedactURL(request.URL.String())},
+		Field{Key: "status", Value: response.StatusCode},
+		Field{Key: "ttfb", Value: timing.snapshot().FirstByte},
+		Field{Key: "cache", Value: resp.cacheStatus},
+	)
+	// 下载限速和进度
+	response.Body = c.wrapTransfer(ctx, response.Body, response.ContentLength, ProgressDownload, p, limiter)
+	response.Body = &hookBody{
+		ReadCloser: response.Body,
+		onDone: func(read int64) {
+			finish(response.StatusCode, read, nil)
+		},
+	}
 	resp.response = response
 
 	return resp
@@ -250,6 +463,7 @@ func (c *Curlx) exec(ctx context.Context, ps ...Param) Response {
 
 /**
  * 流式请求
+ * 设置了响应签名校验时，先读取完整Body校验，通过后再逐行返回，校验失败时不返回任何内容
  */
 func (c *Curlx) SendStream(ctx context.Context, ps ...Param) (<-chan string, error) {
 
@@ -266,7 +480,17 @@ func (c *Curlx) SendStream(ctx context.Context, ps ...Param) (<-chan string, err
 			return
 		}
 		defer response.Close() // 处理完关闭
-		scanner := bufio.NewScanner(response.response.Body)
+
+		var reader io.Reader = response.response.Body
+		if response.verifier != nil {
+			body, err := response.GetBody()
+			if err != nil {
+				c.log(ctx, LevelError, "curlx.SendStream verify failed", Field{Key: "error", Value: err})
+				return
+			}
+			reader = bytes.NewReader(body)
+		}
+		scanner := bufio.NewScanner(reader)
 		for scanner.Scan() {
 			text := scanner.Text()
 			if text == "" {
@@ -0,0 +1,354 @@
+// Package curlxtest 提供用于单元测试的模拟传输，无需启动服务或访问网络
+//
+//	mock := curlxtest.NewMockTransport()
+//	mock.Expect("POST", "https://api.example.com/users/*").
+//		WithJSONBody(`{"name":"bob"}`).
+//		RespondJSON(201, map[string]any{"id": 1})
+//	client := curlx.NewCurlx(mock.Option())
+//	...
+//	mock.AssertExpectations(t)
+package curlxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuninks/curlx"
+)
+
+// TestingT testing.T 的子集
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// MockResponse 模拟的响应
+type MockResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	Err    error         // 不为空时请求返回该错误
+	Delay  time.Duration // 模拟延迟
+}
+
+// Expectation 期望的请求及其响应
+type Expectation struct {
+	method    string
+	pattern   string
+	urlRegexp *regexp.Regexp
+	headers   http.Header
+	body      []byte
+	jsonBody  any
+	hasJSON   bool
+	times     int // 期望调用次数，<0 表示至少一次
+	delay     time.Duration
+	responses []MockResponse
+	calls     int
+}
+
+// MockTransport 模拟的 http.RoundTripper，按期望匹配请求并返回响应
+type MockTransport struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	requests     []*http.Request
+	unmatched    []string
+}
+
+func NewMockTransport() *MockTransport {
+	return &MockTransport{}
+}
+
+/**
+ * 注入到Curlx，作为底层传输处理所有请求，中间件仍然生效
+ */
+func (m *MockTransport) Option() curlx.Option {
+	return curlx.WithOptionTransport(m)
+}
+
+/**
+ * 添加期望
+ * @param method 请求方法，为空时匹配任意方法
+ * @param urlPattern 完整URL或以/开头的路径(含查询参数时一起匹配)，*匹配任意字符
+ */
+func (m *MockTransport) Expect(method, urlPattern string) *Expectation {
+	e := &Expectation{
+		method:    strings.ToUpper(method),
+		pattern:   urlPattern,
+		urlRegexp: globRegexp(urlPattern),
+		headers:   http.Header{},
+		times:     -1,
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+/**
+ * 要求请求头包含指定值
+ */
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	e.headers.Add(key, value)
+	return e
+}
+
+/**
+ * 要求请求Body完全一致
+ */
+func (e *Expectation) WithBody(body string) *Expectation {
+	e.body = []byte(body)
+	return e
+}
+
+/**
+ * 要求请求Body为等价的JSON(忽略格式和字段顺序)
+ * v可以是JSON字符串、[]byte或任意可序列化的值
+ */
+func (e *Expectation) WithJSONBody(v any) *Expectation {
+	var b []byte
+	switch value := v.(type) {
+	case string:
+		b = []byte(value)
+	case []byte:
+		b = value
+	default:
+		b, _ = json.Marshal(value)
+	}
+	var parsed any
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		panic(fmt.Sprintf("curlxtest: invalid JSON body: %v", err))
+	}
+	e.jsonBody = parsed
+	e.hasJSON = true
+	return e
+}
+
+/**
+ * 期望调用的次数，默认至少一次
+ */
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+/**
+ * 每次响应前的模拟延迟
+ */
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+/**
+ * 添加响应，多次调用时按顺序返回，用完后重复返回最后一个
+ */
+func (e *Expectation) RespondWith(r MockResponse) *Expectation {
+	e.responses = append(e.responses, r)
+	return e
+}
+
+func (e *Expectation) Respond(status int, body string) *Expectation {
+	return e.RespondWith(MockResponse{Status: status, Body: []byte(body)})
+}
+
+func (e *Expectation) RespondJSON(status int, v any) *Expectation {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("curlxtest: marshal response: %v", err))
+	}
+	return e.RespondWith(MockResponse{
+		Status: status,
+		Header: http.Header{"Content-Type": []string{"application/json"}},
+		Body:   b,
+	})
+}
+
+/**
+ * 返回网络错误
+ */
+func (e *Expectation) RespondError(err error) *Expectation {
+	return e.RespondWith(MockResponse{Err: err})
+}
+
+func (e *Expectation) String() string {
+	method := e.method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + e.pattern
+}
+
+func (e *Expectation) match(req *http.Request, body []byte) bool {
+	if e.method != "" && e.method != req.Method {
+		return false
+	}
+	target := req.URL.String()
+	if strings.HasPrefix(e.pattern, "/") {
+		target = req.URL.Path
+		if strings.Contains(e.pattern, "?") {
+			target = req.URL.RequestURI()
+		}
+	}
+	if !e.urlRegexp.MatchString(target) {
+		return false
+	}
+	for k, vs := range e.headers {
+		got := req.Header.Values(k)
+		for _, v := range vs {
+			if !contains(got, v) {
+				return false
+			}
+		}
+	}
+	if e.body != nil && !bytes.Equal(e.body, body) {
+		return false
+	}
+	if e.hasJSON {
+		var got any
+		if json.Unmarshal(body, &got) != nil {
+			return false
+		}
+		a, _ := json.Marshal(got)
+		b, _ := json.Marshal(e.jsonBody)
+		if !bytes.Equal(a, b) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * *匹配任意字符，其他字符按原样匹配
+ */
+func globRegexp(pattern string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(pattern)
+	return regexp.MustCompile("^" + strings.ReplaceAll(quoted, `\*`, ".*") + "$")
+}
+
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+
+	m.mu.Lock()
+	m.requests = append(m.requests, req)
+	var (
+		matched *Expectation
+		resp    MockResponse
+	)
+	for _, e := range m.expectations {
+		// 已达到期望次数的跳过，便于同一请求按顺序设置多个期望
+		if !e.match(req, body) || (e.times >= 0 && e.calls >= e.times) {
+			continue
+		}
+		matched = e
+		break
+	}
+	if matched == nil {
+		m.unmatched = append(m.unmatched, req.Method+" "+req.URL.String())
+		m.mu.Unlock()
+		return nil, fmt.Errorf("curlxtest: no expectation matches %s %s", req.Method, req.URL)
+	}
+	if len(matched.responses) > 0 {
+		resp = matched.responses[min(matched.calls, len(matched.responses)-1)]
+	} else {
+		resp = MockResponse{Status: http.StatusOK}
+	}
+	matched.calls++
+	delay := matched.delay + resp.Delay
+	m.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	if resp.Err != nil {
+		return nil, resp.Err
+	}
+
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := http.Header{}
+	for k, v := range resp.Header {
+		header[k] = append([]string{}, v...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}, nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+/**
+ * 收到的全部请求(Body已读取)
+ */
+func (m *MockTransport) Requests() []*http.Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*http.Request{}, m.requests...)
+}
+
+/**
+ * 检查所有期望都已满足，且没有未匹配的请求
+ */
+func (m *MockTransport) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+	for _, e := range m.expectations {
+		switch {
+		case e.times < 0 && e.calls == 0:
+			t.Errorf("curlxtest: expected %s to be called, but it was not", e)
+			ok = false
+		case e.times >= 0 && e.calls != e.times:
+			t.Errorf("curlxtest: expected %s to be called %d times, got %d", e, e.times, e.calls)
+			ok = false
+		}
+	}
+	for _, u := range m.unmatched {
+		t.Errorf("curlxtest: unexpected request %s", u)
+		ok = false
+	}
+	return ok
+}
@@ -0,0 +1,119 @@
+package curlxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yuninks/curlx"
+)
+
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockTransport(t *testing.T) {
+	mock := NewMockTransport()
+	mock.Expect("POST", "https://api.example.com/users/*").
+		WithHeader("X-Token", "abc").
+		WithJSONBody(map[string]any{"name": "bob", "age": 3}).
+		RespondJSON(201, map[string]any{"id": 1})
+	mock.Expect("GET", "/ping").Respond(200, "pong")
+
+	c := curlx.NewCurlx(mock.Option())
+	ctx := context.Background()
+
+	resp := c.SendWithResponse(ctx,
+		curlx.SetParamsUrl("https://api.example.com/users/42"),
+		curlx.SetParamsMethod(curlx.MethodPost),
+		curlx.SetParamsHeader("X-Token", "abc"),
+		curlx.SetParamsBody([]byte(`{"age":3, "name":"bob"}`)),
+		curlx.SetParamsContentType(curlx.ContentTypeJson),
+	)
+	if resp.GetStatusCode() != 201 {
+		t.Fatalf("status = %d, err = %v", resp.GetStatusCode(), resp.GetError())
+	}
+	body, _ := resp.GetBody()
+	if string(body) != `{"id":1}` {
+		t.Errorf("body = %s", body)
+	}
+
+	body, err := c.Send(ctx, curlx.SetParamsUrl("http://localhost/ping?x=1"), curlx.SetParamsMethod(curlx.MethodGet))
+	if err != nil || string(body) != "pong" {
+		t.Fatalf("body = %s, err = %v", body, err)
+	}
+
+	mock.AssertExpectations(t)
+}
+
+func TestMockTransportSequence(t *testing.T) {
+	mock := NewMockTransport()
+	mock.Expect("GET", "/retry").
+		Respond(503, "busy").
+		Respond(200, "ok").
+		Times(2)
+
+	c := curlx.NewCurlx(mock.Option())
+	body, err := c.Send(context.Background(),
+		curlx.SetParamsUrl("http://localhost/retry"),
+		curlx.SetParamsMethod(curlx.MethodGet),
+		curlx.SetParamsRetry(1, time.Millisecond),
+	)
+	if err != nil || string(body) != "ok" {
+		t.Fatalf("body = %s, err = %v", body, err)
+	}
+	mock.AssertExpectations(t)
+}
+
+func TestMockTransportErrorAndDelay(t *testing.T) {
+	mock := NewMockTransport()
+	boom := errors.New("boom")
+	mock.Expect("GET", "/error").RespondError(boom)
+	mock.Expect("GET", "/slow").Delay(time.Second).Respond(200, "late")
+
+	c := curlx.NewCurlx(mock.Option())
+	_, err := c.Send(context.Background(), curlx.SetParamsUrl("http://localhost/error"), curlx.SetParamsMethod(curlx.MethodGet))
+	if !errors.Is(err, boom) {
+		t.Errorf("err = %v, want boom", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.Send(ctx, curlx.SetParamsUrl("http://localhost/slow"), curlx.SetParamsMethod(curlx.MethodGet))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want deadline exceeded", err)
+	}
+	mock.AssertExpectations(t)
+}
+
+func TestMockTransportAssert(t *testing.T) {
+	mock := NewMockTransport()
+	mock.Expect("GET", "/never")
+	mock.Expect("GET", "/once").Times(2)
+
+	c := curlx.NewCurlx(mock.Option())
+	ctx := context.Background()
+	c.Send(ctx, curlx.SetParamsUrl("http://localhost/once"), curlx.SetParamsMethod(curlx.MethodGet))
+	if _, err := c.Send(ctx, curlx.SetParamsUrl("http://localhost/other"), curlx.SetParamsMethod(curlx.MethodGet)); err == nil {
+		t.Error("unmatched request should fail")
+	}
+
+	ft := &fakeT{}
+	if mock.AssertExpectations(ft) {
+		t.Error("AssertExpectations should fail")
+	}
+	if len(ft.errors) != 3 {
+		t.Errorf("errors = %q", ft.errors)
+	}
+	if len(mock.Requests()) != 2 {
+		t.Errorf("requests = %d", len(mock.Requests()))
+	}
+}
@@ -0,0 +1,216 @@
+package curlx
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupKeyFunc 计算请求合并的key，返回空字符串时不合并
+// 参数的Url为最终请求的地址，已填充路径参数、按BaseURL解析并合并查询参数
+type DedupKeyFunc func(p ClientParams) string
+
+/**
+ * 合并相同的进行中请求，只发送一次，每个调用方得到已读取Body的独立响应
+ * 默认只合并GET/HEAD，key由方法、URL和指定的请求头组成
+ */
+func WithOptionDedup(headers ...string) Option {
+	return WithOptionDedupKey(DefaultDedupKey(headers...))
+}
+
+/**
+ * 使用自定义key合并请求
+ */
+func WithOptionDedupKey(fn DedupKeyFunc) Option {
+	return func(options *ClientOptions) {
+		options.DedupKey = fn
+	}
+}
+
+/**
+ * 默认的合并key：方法+URL+指定请求头，只合并GET/HEAD
+ * 凭证相关的请求头(默认脱敏规则中的Authorization、Cookie、X-Api-Key等)和Cookie总是包含在key中，
+ * 不同凭证的请求不会合并；设置了请求的认证(SetParamsAuth)时无法区分凭证，不合并
+ */
+func DefaultDedupKey(headers ...string) DedupKeyFunc {
+	names := make([]string, 0, len(headers))
+	for _, h := range headers {
+		names = append(names, http.CanonicalHeaderKey(h))
+	}
+	for _, h := range DefaultRedactRules().Headers {
+		if name := http.CanonicalHeaderKey(h); !containsFold(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return func(p ClientParams) string {
+		method := strings.ToUpper(string(p.Method))
+		if method != http.MethodGet && method != http.MethodHead {
+			return ""
+		}
+		if p.Auth != nil {
+			return ""
+		}
+		var b strings.Builder
+		b.WriteString(method)
+		b.WriteString(" ")
+		if rawURL, err := withQuery(p.Url, p.Query); err == nil {
+			b.WriteString(rawURL)
+		} else {
+			b.WriteString(p.Url)
+		}
+		for _, name := range names {
+			b.WriteString("\n")
+			b.WriteString(name)
+			b.WriteString(": ")
+			b.WriteString(strings.Join(p.Headers.Values(name), ","))
+		}
+		for _, cookie := range p.Cookies {
+			b.WriteString("\nCookie: ")
+			b.WriteString(cookie.Name + "=" + cookie.Value)
+		}
+		return b.String()
+	}
+}
+
+/**
+ * 计算合并key使用的参数，与 execOnce 一样解析出最终请求的URL
+ * 路径参数和查询参数已合并到Url，GET请求的Body参数也已合并到查询参数
+ * 只处理影响URL的部分，不构建请求Body
+ */
+func (c *Curlx) dedupParams(p ClientParams) (ClientParams, error) {
+	cp := p.clone()
+	if err := cp.parseUrl(c.opts.BaseURL); err != nil {
+		return cp, err
+	}
+	cp.PathParams = nil
+	cp.Query = nil
+
+	// 与 parseParams 一致，未指定数据类型的GET请求Body合并到URL
+	switch cp.ContentType {
+	case ContentTypeJson, ContentTypeForm, ContentTypeXml, ContentTypeText, ContentTypeUrlEncoded:
+	default:
+		if len(cp.Body) > 0 && cp.Method == MethodGet {
+			if err := cp.parseBodyQuery(); err != nil {
+				return cp, err
+			}
+		}
+	}
+	return cp, nil
+}
+
+type dedupCall struct {
+	done    chan struct{}
+	resp    Response
+	panic   any                // fn的panic，在等待的调用方中重新抛出
+	cancel  context.CancelFunc // 所有调用方都放弃等待时取消请求
+	waiters int
+}
+
+// detachedContext 保留上下文中的值，不随原上下文取消
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any         { return c.parent.Value(key) }
+
+// dedupGroup 合并相同key的进行中请求
+type dedupGroup struct {
+	mu    sync.Mutex
+	calls map[string]*dedupCall
+}
+
+func newDedupGroup() *dedupGroup {
+	return &dedupGroup{calls: map[string]*dedupCall{}}
+}
+
+/**
+ * 相同key只执行一次fn
+ * fn使用第一个调用方ctx中的值，但不随其取消也没有截止时间，由客户端的超时控制，
+ * 每个调用方通过自己的ctx放弃等待，所有调用方都放弃后取消请求
+ * fn的panic在每个等待的调用方中重新抛出
+ */
+func (g *dedupGroup) do(ctx context.Context, key string, fn func(ctx context.Context) Response) Response {
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if !ok {
+		shared, cancel := context.WithCancel(detachedContext{parent: ctx})
+		call = &dedupCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go g.run(shared, key, call, fn)
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.panic != nil {
+			panic(call.panic)
+		}
+		return call.resp.copy()
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			// 之后相同的请求重新发送，不使用已取消的请求
+			g.remove(key, call)
+			call.cancel()
+		}
+		g.mu.Unlock()
+		return Response{err: ctx.Err(), requestID: RequestIDFromContext(ctx)}
+	}
+}
+
+func (g *dedupGroup) run(ctx context.Context, key string, call *dedupCall, fn func(ctx context.Context) Response) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.panic = r
+		}
+		g.mu.Lock()
+		g.remove(key, call)
+		g.mu.Unlock()
+		call.cancel()
+		close(call.done)
+	}()
+
+	resp := fn(ctx)
+	// 读取Body，供所有调用方共享
+	if resp.err == nil {
+		if _, err := resp.GetBody(); err != nil {
+			resp.err = err
+		}
+	}
+	call.resp = resp
+}
+
+func (g *dedupGroup) remove(key string, call *dedupCall) {
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+}
+
+/**
+ * 复制已读取Body的响应，Body可重复读取
+ */
+func (r Response) copy() Response {
+	cp := r
+	if r.body != nil {
+		cp.body = append([]byte(nil), r.body...)
+	}
+	if r.response != nil {
+		hr := *r.response
+		hr.Header = r.response.Header.Clone()
+		hr.Body = io.NopCloser(bytes.NewReader(cp.body))
+		cp.response = &hr
+	}
+	return cp
+}
@@ -0,0 +1,225 @@
+package curlx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte("shared:" + r.Header.Get("X-Tenant")))
+	}))
+	defer srv.Close()
+
+	c := NewCurlx(WithOptionDedup("X-Tenant"))
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]Response, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tenant := "a"
+			if i%2 == 1 {
+				tenant = "b"
+			}
+			results[i] = c.SendWithResponse(context.Background(),
+				SetParamsUrl(srv.URL),
+				SetParamsMethod(MethodGet),
+				SetParamsHeader("X-Tenant", tenant),
+			)
+		}(i)
+	}
+	// 等待请求都进入等待状态
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+	for i, resp := range results {
+		want := "shared:a"
+		if i%2 == 1 {
+			want = "shared:b"
+		}
+		if string(resp.body) != want {
+			t.Errorf("result %d body = %q, want %q", i, resp.body, want)
+		}
+		body, err := resp.GetBody()
+		if err != nil || string(body) != want {
+			t.Errorf("result %d GetBody = %q, %v", i, body, err)
+		}
+	}
+	// 每个调用方的Body相互独立
+	results[0].body[0] = 'X'
+	if results[2].body[0] != 's' {
+		t.Error("bodies should not share memory")
+	}
+}
+
+func TestDedupKey(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	key := DefaultDedupKey()
+	if key(ClientParams{Method: MethodPost, Url: srv.URL}) != "" {
+		t.Error("POST should not be deduplicated by default")
+	}
+
+	c := NewCurlx(WithOptionDedupKey(func(p ClientParams) string { return "all" }))
+	for i := 0; i < 3; i++ {
+		body, err := c.Send(context.Background(), SetParamsUrl(srv.URL), SetParamsMethod(MethodPost))
+		if err != nil || string(body) != "ok" {
+			t.Fatalf("body = %q, err = %v", body, err)
+		}
+	}
+	// 请求完成后不再合并
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestDedupFinalURL(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte(r.URL.RequestURI()))
+	}))
+	defer srv.Close()
+
+	c := NewCurlx(WithOptionBaseURL(srv.URL), WithOptionDedup())
+	requests := [][]Param{
+		{SetParamsUrl("/users/{id}"), SetParamsPathParam("id", "1")},
+		{SetParamsUrl("/users/{id}"), SetParamsPathParam("id", "2")},
+		{SetParamsUrl("/search"), SetParamsBodyAny(map[string]any{"q": "a"})},
+		{SetParamsUrl("/search"), SetParamsBodyAny(map[string]any{"q": "b"})},
+	}
+	want := []string{"/users/1", "/users/2", "/search?q=a", "/search?q=b"}
+
+	var wg sync.WaitGroup
+	results := make([]Response, len(requests))
+	for i, ps := range requests {
+		wg.Add(1)
+		go func(i int, ps []Param) {
+			defer wg.Done()
+			results[i] = c.SendWithResponse(context.Background(), append(ps, SetParamsMethod(MethodGet))...)
+		}(i, ps)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != int32(len(requests)) {
+		t.Errorf("calls = %d, want %d", calls, len(requests))
+	}
+	for i, resp := range results {
+		if body, _ := resp.GetBody(); string(body) != want[i] {
+			t.Errorf("result %d body = %q, want %q", i, body, want[i])
+		}
+	}
+}
+
+func TestDedupCredentials(t *testing.T) {
+	key := DefaultDedupKey()
+	get := func(ps ...Param) string {
+		return key(applyParams(append([]Param{SetParamsUrl("https://example.com/me"), SetParamsMethod(MethodGet)}, ps...)))
+	}
+	keys := map[string]bool{}
+	for _, ps := range [][]Param{
+		{SetParamsHeader("Authorization", "Bearer alice")},
+		{SetParamsHeader("Authorization", "Bearer bob")},
+		{SetParamsHeader("X-Api-Key", "alice")},
+		{SetCookie("sid", "alice")},
+		{SetCookie("sid", "bob")},
+	} {
+		k := get(ps...)
+		if k == "" || keys[k] {
+			t.Errorf("key %q should be unique", k)
+		}
+		keys[k] = true
+	}
+	// 请求的认证无法区分凭证，不合并
+	if k := get(SetParamsAuth(BearerAuth("alice"))); k != "" {
+		t.Errorf("auth key = %q, want empty", k)
+	}
+
+	// 计算key不构建multipart Body
+	c := NewCurlx()
+	dp, err := c.dedupParams(applyParams([]Param{SetParamsUrl("https://example.com"), SetParamsMethod(MethodGet), SetParamsContentType(ContentTypeForm), SetParamsFormText("a", "1")}))
+	if err != nil || dp.Headers.Get("Content-Type") != "" {
+		t.Errorf("dedupParams = %v %v", dp.Headers, err)
+	}
+}
+
+func TestDedupLeaderCanceled(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewCurlx(WithOptionDedup())
+	ps := []Param{SetParamsUrl(srv.URL), SetParamsMethod(MethodGet)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan Response)
+	go func() { leader <- c.SendWithResponse(ctx, ps...) }()
+	time.Sleep(50 * time.Millisecond)
+	follower := make(chan Response)
+	go func() { follower <- c.SendWithResponse(context.Background(), ps...) }()
+	time.Sleep(50 * time.Millisecond)
+
+	// 第一个调用方取消只影响自己
+	cancel()
+	if resp := <-leader; !errors.Is(resp.GetError(), context.Canceled) {
+		t.Errorf("leader err = %v", resp.GetError())
+	}
+	close(release)
+	resp := <-follower
+	if body, err := resp.GetBody(); err != nil || string(body) != "ok" {
+		t.Errorf("follower = %q %v", body, err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDedupPanic(t *testing.T) {
+	g := newDedupGroup()
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recover = %v", r)
+			}
+		}()
+		g.do(context.Background(), "k", func(ctx context.Context) Response { panic("boom") })
+	}()
+
+	// panic后不再占用key
+	resp := g.do(context.Background(), "k", func(ctx context.Context) Response {
+		return Response{body: []byte("ok")}
+	})
+	if string(resp.body) != "ok" || len(g.calls) != 0 {
+		t.Errorf("after panic = %q, calls = %d", resp.body, len(g.calls))
+	}
+}
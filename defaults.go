@@ -0,0 +1,148 @@
+package curlx
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// DefaultUserAgent 默认的User-Agent
+const DefaultUserAgent = "curlx/" + Version
+
+/**
+ * 设置客户端默认的请求头，多次设置同一个请求头时保留所有值
+ * 请求设置了同名请求头时使用请求的值
+ */
+func WithOptionHeader(key, value string) Option {
+	return func(options *ClientOptions) {
+		if options.Headers == nil {
+			options.Headers = http.Header{}
+		}
+		options.Headers.Add(key, value)
+	}
+}
+
+/**
+ * 批量设置客户端默认的请求头
+ */
+func WithOptionHeaders(h map[string]string) Option {
+	return func(options *ClientOptions) {
+		if options.Headers == nil {
+			options.Headers = http.Header{}
+		}
+		for k, v := range h {
+			options.Headers.Set(k, v)
+		}
+	}
+}
+
+/**
+ * 设置客户端默认的Cookie，请求设置了同名Cookie时使用请求的值
+ */
+func WithOptionCookies(cookies ...http.Cookie) Option {
+	return func(options *ClientOptions) {
+		options.Cookies = append(options.Cookies, cookies...)
+	}
+}
+
+/**
+ * 设置客户端默认的查询参数
+ * 请求的Url或 SetParamsQuery 中已有同名参数时不添加
+ */
+func WithOptionQuery(key string, values ...string) Option {
+	return func(options *ClientOptions) {
+		if options.Query == nil {
+			options.Query = url.Values{}
+		}
+		options.Query[key] = append(options.Query[key], values...)
+	}
+}
+
+/**
+ * 设置客户端默认的数据类型，请求未设置时使用
+ */
+func WithOptionContentType(t ContentType) Option {
+	return func(options *ClientOptions) {
+		options.ContentType = t
+	}
+}
+
+/**
+ * 设置默认的User-Agent，默认为 curlx/<版本号>，为空时使用Go默认值
+ * 优先级: 请求的请求头 > 客户端默认请求头 > 此设置
+ */
+func WithOptionUserAgent(userAgent string) Option {
+	return func(options *ClientOptions) {
+		options.UserAgent = userAgent
+	}
+}
+
+/**
+ * 构建请求参数并合并客户端的默认值
+ */
+func (c *Curlx) newParams(ps ...Param) ClientParams {
+	p := defaultParams()
+	for _, param := range ps {
+		param(&p)
+	}
+	c.opts.applyDefaults(&p)
+	return p
+}
+
+/**
+ * 合并默认值，请求已设置的值优先
+ * 请求头和Cookie按名称覆盖，查询参数按key覆盖
+ */
+func (o *ClientOptions) applyDefaults(p *ClientParams) {
+	if p.ContentType == "" {
+		p.ContentType = o.ContentType
+	}
+
+	if len(o.Headers) > 0 {
+		// 复制后合并，避免修改调用方传入的Header
+		headers := o.Headers.Clone()
+		for k, v := range p.Headers {
+			headers[k] = v
+		}
+		p.Headers = headers
+	}
+
+	if len(o.Cookies) > 0 {
+		cookies := make([]http.Cookie, 0, len(o.Cookies)+len(p.Cookies))
+		for _, cookie := range o.Cookies {
+			if !hasCookie(p.Cookies, cookie.Name) {
+				cookies = append(cookies, cookie)
+			}
+		}
+		p.Cookies = append(cookies, p.Cookies...)
+	}
+
+	if len(o.Query) > 0 {
+		var inURL url.Values
+		if u, err := url.Parse(p.Url); err == nil {
+			inURL = u.Query()
+		}
+		query := url.Values{}
+		for k, v := range p.Query {
+			query[k] = v
+		}
+		for k, v := range o.Query {
+			if _, ok := query[k]; ok {
+				continue
+			}
+			if _, ok := inURL[k]; ok {
+				continue
+			}
+			query[k] = append([]string(nil), v...)
+		}
+		p.Query = query
+	}
+}
+
+func hasCookie(cookies []http.Cookie, name string) bool {
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package curlx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientDefaults(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookies := map[string]string{}
+		for _, c := range r.Cookies() {
+			cookies[c.Name] = c.Value
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"ua":      r.Header.Get("User-Agent"),
+			"tenant":  r.Header.Values("X-Tenant"),
+			"key":     r.Header.Get("X-Api-Key"),
+			"type":    r.Header.Get("Content-Type"),
+			"query":   r.URL.RawQuery,
+			"cookies": cookies,
+		})
+	}))
+	defer srv.Close()
+
+	type result struct {
+		UA      string            `json:"ua"`
+		Tenant  []string          `json:"tenant"`
+		Key     string            `json:"key"`
+		Type    string            `json:"type"`
+		Query   string            `json:"query"`
+		Cookies map[string]string `json:"cookies"`
+	}
+	send := func(c *Curlx, ps ...Param) result {
+		t.Helper()
+		ps = append([]Param{SetParamsMethod(MethodPost), SetParamsBody([]byte(`{}`))}, ps...)
+		body, err := c.Send(context.Background(), ps...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r result
+		json.Unmarshal(body, &r)
+		return r
+	}
+
+	c := NewCurlx(
+		WithOptionHeader("X-Tenant", "t1"),
+		WithOptionHeader("X-Tenant", "t2"),
+		WithOptionHeaders(map[string]string{"X-Api-Key": "default-key"}),
+		WithOptionCookies(http.Cookie{Name: "session", Value: "s1"}, http.Cookie{Name: "lang", Value: "zh"}),
+		WithOptionQuery("version", "2"),
+		WithOptionQuery("region", "cn"),
+		WithOptionContentType(ContentTypeJson),
+	)
+
+	r := send(c, SetParamsUrl(srv.URL))
+	if r.UA != "curlx/"+Version || len(r.Tenant) != 2 || r.Key != "default-key" || r.Type != "application/json" {
+		t.Errorf("defaults = %+v", r)
+	}
+	if r.Query != "region=cn&version=2" || r.Cookies["session"] != "s1" || r.Cookies["lang"] != "zh" {
+		t.Errorf("defaults = %+v", r)
+	}
+
+	// 请求的值优先
+	r = send(c,
+		SetParamsUrl(srv.URL+"?region=us"),
+		SetParamsHeader("X-Tenant", "t3"),
+		SetUserAgent("my-app/1.0"),
+		SetCookie("session", "s2"),
+		SetParamsQuery("version", "3"),
+		SetParamsContentType(ContentTypeText),
+	)
+	if r.UA != "my-app/1.0" || len(r.Tenant) != 1 || r.Tenant[0] != "t3" || r.Key != "default-key" || r.Type != "text/plain" {
+		t.Errorf("overrides = %+v", r)
+	}
+	if r.Query != "region=us&version=3" || r.Cookies["session"] != "s2" || r.Cookies["lang"] != "zh" {
+		t.Errorf("overrides = %+v", r)
+	}
+
+	// 自定义默认UA，请求头中的UA优先
+	r = send(NewCurlx(WithOptionUserAgent("svc/2")), SetParamsUrl(srv.URL), SetParamsContentType(ContentTypeJson))
+	if r.UA != "svc/2" {
+		t.Errorf("ua = %q", r.UA)
+	}
+	r = send(NewCurlx(WithOptionUserAgent("svc/2"), WithOptionHeader("User-Agent", "hdr/1")), SetParamsUrl(srv.URL), SetParamsContentType(ContentTypeJson))
+	if r.UA != "hdr/1" {
+		t.Errorf("ua = %q", r.UA)
+	}
+}
+
+func TestClientDefaultsNotShared(t *testing.T) {
+	c := NewCurlx(WithOptionHeader("X-Default", "1"))
+	headers := http.Header{"X-Own": {"1"}}
+	p := c.newParams(SetParamsAll(ClientParams{Headers: headers}))
+	p.Headers.Set("X-Added", "1")
+	if len(headers) != 1 || len(c.opts.Headers) != 1 {
+		t.Errorf("headers = %v, defaults = %v", headers, c.opts.Headers)
+	}
+}
@@ -0,0 +1,540 @@
+package curlx
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrChecksumMismatch 下载文件的校验和不一致
+var ErrChecksumMismatch = errors.New("curlx: checksum mismatch")
+
+// ErrDownloadStalled 下载超过空闲时间没有收到数据
+var ErrDownloadStalled = errors.New("curlx: download stalled")
+
+// 分段下载时文件已变化
+var errDownloadChanged = errors.New("curlx: file changed during download")
+
+// DownloadProgress 下载进度
+type DownloadProgress struct {
+	Downloaded int64         // 已下载字节数(含之前断点的部分)
+	Total      int64         // 总字节数，未知时为-1
+	Rate       float64       // 本次下载速度 字节/秒
+	ETA        time.Duration // 预计剩余时间，总大小未知时为0
+}
+
+// DownloadOption 下载选项
+type DownloadOption func(*downloadOptions)
+
+type downloadOptions struct {
+	params       []Param
+	checksumAlgo string
+	checksum     string
+	progress     func(DownloadProgress)
+	chunks       int
+	idleTimeout  time.Duration
+}
+
+/**
+ * 设置请求参数，如请求头、重试策略
+ */
+func WithDownloadParams(ps ...Param) DownloadOption {
+	return func(o *downloadOptions) {
+		o.params = append(o.params, ps...)
+	}
+}
+
+/**
+ * 下载完成后校验文件
+ * @param algo sha256/md5
+ * @param sum 十六进制校验和
+ */
+func WithDownloadChecksum(algo, sum string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.checksumAlgo = strings.ToLower(algo)
+		o.checksum = strings.ToLower(sum)
+	}
+}
+
+/**
+ * 下载进度回调，按顺序调用
+ */
+func WithDownloadProgress(fn func(p DownloadProgress)) DownloadOption {
+	return func(o *downloadOptions) {
+		o.progress = fn
+	}
+}
+
+/**
+ * 分块并行下载，服务端不支持Range时退回单连接下载
+ */
+func WithDownloadChunks(n int) DownloadOption {
+	return func(o *downloadOptions) {
+		o.chunks = n
+	}
+}
+
+/**
+ * 读取空闲超时，超过该时间没有收到数据时返回 ErrDownloadStalled
+ * 默认使用 WithOptionTimeOut 的值
+ */
+func WithDownloadIdleTimeout(d time.Duration) DownloadOption {
+	return func(o *downloadOptions) {
+		o.idleTimeout = d
+	}
+}
+
+// downloadMeta 断点信息，保存在临时文件旁
+type downloadMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Total        int64  `json:"total"`
+	ChunkSize    int64  `json:"chunk_size,omitempty"`
+	Chunks       []bool `json:"chunks,omitempty"` // 已完成的分块
+}
+
+/**
+ * If-Range使用的验证器，弱ETag不能用于Range请求
+ */
+func (m *downloadMeta) validator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+type downloader struct {
+	c        *Curlx
+	ctx      context.Context
+	url      string
+	part     string
+	metaPath string
+	opts     downloadOptions
+	progress *progressReporter
+}
+
+/**
+ * 下载文件
+ * 先写入 destPath.part，中断后再次调用会通过Range续传，完成并校验后重命名为destPath
+ * 下载不受 WithOptionTimeOut 的总时间限制，由ctx控制，每个连接超过空闲时间没有收到数据时失败
+ * 分段和续传的响应不是完整Body，不做响应签名校验(WithOptionVerifier)，需要时使用 WithDownloadChecksum
+ */
+func (c *Curlx) Download(ctx context.Context, url, destPath string, opts ...DownloadOption) error {
+	o := downloadOptions{idleTimeout: c.opts.TimeOut}
+	for _, apply := range opts {
+		apply(&o)
+	}
+
+	var newHash func() hash.Hash
+	switch o.checksumAlgo {
+	case "":
+	case "sha256":
+		newHash = sha256.New
+	case "md5":
+		newHash = md5.New
+	default:
+		return fmt.Errorf("curlx: unsupported checksum algorithm %q", o.checksumAlgo)
+	}
+
+	d := &downloader{
+		c:        c,
+		ctx:      ensureRequestID(ctx),
+		url:      url,
+		part:     destPath + ".part",
+		metaPath: destPath + ".part.json",
+		opts:     o,
+		progress: newProgressReporter(ProgressDownload, downloadProgressFunc(o.progress)),
+	}
+
+	meta := d.loadMeta()
+	var err error
+	if o.chunks > 1 {
+		err = d.parallel(meta)
+	} else {
+		err = d.single(meta)
+	}
+	if err != nil {
+		return err
+	}
+	d.progress.finish()
+
+	if newHash != nil {
+		sum, err := fileChecksum(d.part, newHash())
+		if err != nil {
+			return err
+		}
+		if sum != o.checksum {
+			os.Remove(d.part)
+			os.Remove(d.metaPath)
+			return fmt.Errorf("%w: got %s, want %s", ErrChecksumMismatch, sum, o.checksum)
+		}
+	}
+
+	if err := os.Rename(d.part, destPath); err != nil {
+		return err
+	}
+	os.Remove(d.metaPath)
+	return nil
+}
+
+func downloadProgressFunc(fn func(DownloadProgress)) func(Progress) {
+	if fn == nil {
+		return nil
+	}
+	return func(p Progress) {
+		fn(DownloadProgress{Downloaded: p.Transferred, Total: p.Total, Rate: p.Rate, ETA: p.ETA})
+	}
+}
+
+/**
+ * 发送GET请求，不参与请求合并，不使用客户端的总超时
+ * 超过空闲时间没有收到响应或数据时取消请求
+ * 处理完响应后调用返回的finish，请求因空闲取消时把错误转为 ErrDownloadStalled
+ */
+func (d *downloader) get(ctx context.Context, header map[string]string) (Response, func(err error) error) {
+	ps := append([]Param{SetParamsUrl(d.url), SetParamsMethod(MethodGet)}, d.opts.params...)
+	p := d.c.newParams(ps...)
+	for k, v := range header {
+		p.Headers.Set(k, v)
+	}
+	p.noTimeout = true
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	idle := d.opts.idleTimeout
+	var timer *time.Timer
+	if idle > 0 {
+		timer = time.AfterFunc(idle, func() { cancel(ErrDownloadStalled) })
+	}
+	finish := func(err error) error {
+		if timer != nil {
+			timer.Stop()
+		}
+		stalled := errors.Is(context.Cause(ctx), ErrDownloadStalled)
+		cancel(nil)
+		if err != nil && stalled {
+			return fmt.Errorf("%w: no data for %s", ErrDownloadStalled, idle)
+		}
+		return err
+	}
+
+	resp := d.c.execRetry(ctx, p)
+	if timer != nil && resp.response != nil {
+		timer.Reset(idle)
+		resp.response.Body = &idleBody{ReadCloser: resp.response.Body, reset: func() { timer.Reset(idle) }}
+	}
+	return resp, finish
+}
+
+// idleBody 收到数据时重置空闲计时
+type idleBody struct {
+	io.ReadCloser
+	reset func()
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.reset()
+	}
+	return n, err
+}
+
+/**
+ * 单连接下载，存在断点时续传
+ */
+func (d *downloader) single(meta *downloadMeta) (err error) {
+	var offset int64
+	if meta != nil && meta.Chunks == nil && meta.validator() != "" {
+		if fi, err := os.Stat(d.part); err == nil {
+			offset = fi.Size()
+		}
+	}
+
+	header := map[string]string{"Range": fmt.Sprintf("bytes=%d-", offset)}
+	if offset > 0 {
+		header["If-Range"] = meta.validator()
+	}
+	resp, finish := d.get(d.ctx, header)
+	defer func() { err = finish(err) }()
+	if resp.err != nil {
+		return resp.err
+	}
+	defer resp.Close()
+
+	total := int64(-1)
+	switch status := resp.GetStatusCode(); status {
+	case 206:
+		start, size, ok := parseContentRange(resp.GetHeaderLine("Content-Range"))
+		if !ok || start != offset {
+			return fmt.Errorf("curlx: unexpected Content-Range %q", resp.GetHeaderLine("Content-Range"))
+		}
+		total = size
+	case 200:
+		// 不支持Range或文件已变化，重新下载
+		offset = 0
+		total = resp.response.ContentLength
+	case 416:
+		_, size, _ := parseContentRange(resp.GetHeaderLine("Content-Range"))
+		if offset > 0 && size == offset {
+			return nil
+		}
+		os.Remove(d.part)
+		os.Remove(d.metaPath)
+		if offset > 0 {
+			return d.single(nil)
+		}
+		return fmt.Errorf("%w: %d", ErrStatusNotOK, status)
+	default:
+		return fmt.Errorf("%w: %d", ErrStatusNotOK, status)
+	}
+
+	meta = &downloadMeta{
+		URL:          d.url,
+		ETag:         resp.GetHeaderLine("ETag"),
+		LastModified: resp.GetHeaderLine("Last-Modified"),
+		Total:        total,
+	}
+	if err := d.saveMeta(meta); err != nil {
+		return err
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if offset == 0 {
+		flag |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(d.part, flag, 0o644)
+	if err != nil {
+		return err
+	}
+	d.progress.begin(offset, total)
+	written, err := io.Copy(&progressWriter{w: f, r: d.progress}, resp.response.Body)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if total >= 0 && offset+written != total {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+/**
+ * 分块并行下载，每个分块完成后记录到断点信息
+ */
+func (d *downloader) parallel(meta *downloadMeta) error {
+	// 探测是否支持Range以及文件大小
+	probe, finish := d.get(d.ctx, map[string]string{"Range": "bytes=0-0"})
+	if probe.err != nil {
+		return finish(probe.err)
+	}
+	probe.Close()
+	finish(nil)
+	_, total, ok := parseContentRange(probe.GetHeaderLine("Content-Range"))
+	current := &downloadMeta{
+		URL:          d.url,
+		ETag:         probe.GetHeaderLine("ETag"),
+		LastModified: probe.GetHeaderLine("Last-Modified"),
+		Total:        total,
+	}
+	if probe.GetStatusCode() != 206 || !ok || total <= 0 || current.validator() == "" {
+		if meta != nil && meta.Chunks != nil {
+			meta = nil
+		}
+		return d.single(meta)
+	}
+
+	n := int64(d.opts.chunks)
+	chunkSize := (total + n - 1) / n
+	count := int((total + chunkSize - 1) / chunkSize)
+
+	flag := os.O_CREATE | os.O_WRONLY
+	if meta == nil || meta.validator() != current.validator() || meta.Total != total ||
+		meta.ChunkSize != chunkSize || len(meta.Chunks) != count {
+		meta = current
+		meta.ChunkSize = chunkSize
+		meta.Chunks = make([]bool, count)
+		flag |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(d.part, flag, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Truncate(total); err != nil {
+		return err
+	}
+	if err := d.saveMeta(meta); err != nil {
+		return err
+	}
+
+	var downloaded int64
+	for i, done := range meta.Chunks {
+		if done {
+			downloaded += chunkEnd(i, chunkSize, total) - int64(i)*chunkSize + 1
+		}
+	}
+	d.progress.begin(downloaded, total)
+
+	ctx, cancel := context.WithCancel(d.ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for i, done := range meta.Chunks {
+		if done {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := d.chunk(ctx, f, meta.validator(), int64(i)*chunkSize, chunkEnd(i, chunkSize, total))
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			meta.Chunks[i] = true
+			d.saveMeta(meta)
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		// 所有分块结束后再删除，避免被其他分块保存的断点信息覆盖
+		if errors.Is(firstErr, errDownloadChanged) {
+			os.Remove(d.metaPath)
+		}
+		return firstErr
+	}
+	return f.Sync()
+}
+
+func chunkEnd(i int, chunkSize, total int64) int64 {
+	end := int64(i+1)*chunkSize - 1
+	if end >= total {
+		end = total - 1
+	}
+	return end
+}
+
+/**
+ * 下载一个分块写入到对应位置
+ */
+func (d *downloader) chunk(ctx context.Context, f *os.File, validator string, start, end int64) (err error) {
+	resp, finish := d.get(ctx, map[string]string{
+		"Range":    fmt.Sprintf("bytes=%d-%d", start, end),
+		"If-Range": validator,
+	})
+	defer func() { err = finish(err) }()
+	if resp.err != nil {
+		return resp.err
+	}
+	defer resp.Close()
+	if resp.GetStatusCode() != 206 {
+		// 文件已变化，下次重新下载
+		return fmt.Errorf("%w: %w: %d", errDownloadChanged, ErrStatusNotOK, resp.GetStatusCode())
+	}
+	if s, _, ok := parseContentRange(resp.GetHeaderLine("Content-Range")); !ok || s != start {
+		return fmt.Errorf("curlx: unexpected Content-Range %q", resp.GetHeaderLine("Content-Range"))
+	}
+
+	w := &progressWriter{w: io.NewOffsetWriter(f, start), r: d.progress}
+	written, err := io.Copy(w, io.LimitReader(resp.response.Body, end-start+1))
+	if err != nil {
+		return err
+	}
+	if written != end-start+1 {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (d *downloader) loadMeta() *downloadMeta {
+	data, err := os.ReadFile(d.metaPath)
+	if err != nil {
+		return nil
+	}
+	meta := &downloadMeta{}
+	if json.Unmarshal(data, meta) != nil || meta.URL != d.url {
+		return nil
+	}
+	return meta
+}
+
+func (d *downloader) saveMeta(meta *downloadMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(d.metaPath, data, 0o644)
+}
+
+/**
+ * 解析 Content-Range: bytes start-end/total 或 bytes *\/total
+ * total未知时为-1
+ */
+func parseContentRange(v string) (start, total int64, ok bool) {
+	v = strings.TrimSpace(v)
+	if !strings.HasPrefix(v, "bytes ") {
+		return 0, 0, false
+	}
+	rng, size, found := strings.Cut(strings.TrimPrefix(v, "bytes "), "/")
+	if !found {
+		return 0, 0, false
+	}
+	total = -1
+	if size != "*" {
+		n, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		total = n
+	}
+	if rng == "*" {
+		return 0, total, true
+	}
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
+
+func fileChecksum(path string, h hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
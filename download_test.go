@@ -0,0 +1,243 @@
+package curlx
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 支持Range和If-Range的文件服务
+func newDownloadServer(content []byte, etag string, ranges *[]string) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "artifact.bin", time.Time{}, bytes.NewReader(content))
+	}))
+}
+
+func TestDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("curlx-download-"), 1000)
+	sum := sha256.Sum256(content)
+	var ranges []string
+	srv := newDownloadServer(content, `"v1"`, &ranges)
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "artifact.bin")
+	var last DownloadProgress
+	err := NewCurlx().Download(context.Background(), srv.URL, dest,
+		WithDownloadChecksum("sha256", hex.EncodeToString(sum[:])),
+		WithDownloadProgress(func(p DownloadProgress) { last = p }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(dest)
+	if !bytes.Equal(got, content) {
+		t.Error("content mismatch")
+	}
+	if last.Downloaded != int64(len(content)) || last.Total != int64(len(content)) {
+		t.Errorf("progress = %+v", last)
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Error("temporary file should be renamed")
+	}
+	if _, err := os.Stat(dest + ".part.json"); !os.IsNotExist(err) {
+		t.Error("meta file should be removed")
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 500)
+	var ranges []string
+	srv := newDownloadServer(content, `"v1"`, &ranges)
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "artifact.bin")
+	os.WriteFile(dest+".part", content[:1234], 0o644)
+	os.WriteFile(dest+".part.json", []byte(`{"url":"`+srv.URL+`","etag":"\"v1\"","total":5000}`), 0o644)
+
+	if err := NewCurlx().Download(context.Background(), srv.URL, dest); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(dest)
+	if !bytes.Equal(got, content) {
+		t.Error("content mismatch")
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=1234-" {
+		t.Errorf("ranges = %v", ranges)
+	}
+
+	// ETag变化后重新下载
+	ranges = nil
+	os.WriteFile(dest+".part", []byte("stale"), 0o644)
+	os.WriteFile(dest+".part.json", []byte(`{"url":"`+srv.URL+`","etag":"\"v0\"","total":5000}`), 0o644)
+	if err := NewCurlx().Download(context.Background(), srv.URL, dest); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = os.ReadFile(dest)
+	if !bytes.Equal(got, content) {
+		t.Error("content mismatch after restart")
+	}
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	var ranges []string
+	srv := newDownloadServer([]byte("payload"), `"v1"`, &ranges)
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "artifact.bin")
+	sum := md5.Sum([]byte("other"))
+	err := NewCurlx().Download(context.Background(), srv.URL, dest,
+		WithDownloadChecksum("md5", hex.EncodeToString(sum[:])))
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("destination should not exist")
+	}
+
+	if err := NewCurlx().Download(context.Background(), srv.URL, dest, WithDownloadChecksum("crc32", "")); err == nil {
+		t.Error("unsupported algorithm should fail")
+	}
+}
+
+func TestDownloadChunks(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghijklmnopqrstuvwxyz"), 400)
+	sum := sha256.Sum256(content)
+	var ranges []string
+	srv := newDownloadServer(content, `"v1"`, &ranges)
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "artifact.bin")
+	err := NewCurlx().Download(context.Background(), srv.URL, dest,
+		WithDownloadChunks(4),
+		WithDownloadChecksum("sha256", hex.EncodeToString(sum[:])),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(dest)
+	if !bytes.Equal(got, content) {
+		t.Error("content mismatch")
+	}
+	// 探测请求 + 4个分块
+	if len(ranges) != 5 || ranges[0] != "bytes=0-0" {
+		t.Errorf("ranges = %v", ranges)
+	}
+	for _, r := range ranges[1:] {
+		if !strings.HasPrefix(r, "bytes=") || !strings.Contains(r, "-") {
+			t.Errorf("range = %q", r)
+		}
+	}
+}
+
+func TestDownloadTimeout(t *testing.T) {
+	// 每50ms发送一段，总时间超过客户端超时
+	stall := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "10")
+		for i := 0; i < 10; i++ {
+			if r.URL.Path == "/stall" && i == 2 {
+				select {
+				case <-stall:
+				case <-r.Context().Done():
+				}
+				return
+			}
+			w.Write([]byte("x"))
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+	defer close(stall)
+
+	c := NewCurlx(WithOptionTimeOut(200 * time.Millisecond))
+	dest := filepath.Join(t.TempDir(), "slow.bin")
+	if err := c.Download(context.Background(), srv.URL+"/slow", dest); err != nil {
+		t.Fatalf("slow download: %v", err)
+	}
+	if b, _ := os.ReadFile(dest); string(b) != "xxxxxxxxxx" {
+		t.Errorf("content = %q", b)
+	}
+
+	// 空闲超时
+	start := time.Now()
+	err := c.Download(context.Background(), srv.URL+"/stall", dest+"2", WithDownloadIdleTimeout(100*time.Millisecond))
+	if !errors.Is(err, ErrDownloadStalled) {
+		t.Errorf("err = %v, want ErrDownloadStalled", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("stalled download took %s", d)
+	}
+
+	// ctx控制整个下载
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	if err := c.Download(ctx, srv.URL+"/slow", dest+"3"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestDownloadChunksChanged(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghijklmnopqrstuvwxyz"), 400)
+	var mu sync.Mutex
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		// 探测后文件变化，其中一个分块收到完整响应
+		etag := `"v1"`
+		if n == 3 {
+			etag = `"v2"`
+		}
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "artifact.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "artifact.bin")
+	err := NewCurlx().Download(context.Background(), srv.URL, dest, WithDownloadChunks(4))
+	if !errors.Is(err, ErrStatusNotOK) {
+		t.Fatalf("err = %v, want ErrStatusNotOK", err)
+	}
+	// 文件变化后删除断点信息，下次重新下载
+	if _, err := os.Stat(dest + ".part.json"); !os.IsNotExist(err) {
+		t.Errorf("meta file should be removed: %v", err)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	cases := []struct {
+		in           string
+		start, total int64
+		ok           bool
+	}{
+		{"bytes 0-99/1000", 0, 1000, true},
+		{"bytes 100-199/*", 100, -1, true},
+		{"bytes */1000", 0, 1000, true},
+		{"items 0-1/2", 0, 0, false},
+	}
+	for _, c := range cases {
+		start, total, ok := parseContentRange(c.in)
+		if start != c.start || total != c.total || ok != c.ok {
+			t.Errorf("%q = %d %d %v", c.in, start, total, ok)
+		}
+	}
+}
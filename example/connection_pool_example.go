@@ -3,8 +3,6 @@ package example
 import (
 	"context"
 	"fmt"
-	"net/http"
-	"sync"
 	"time"
 
 	"github.com/yuninks/curlx"
@@ -12,8 +10,7 @@ import (
 
 // ConnectionPoolManager 连接池管理器
 type ConnectionPoolManager struct {
-	client    *curlx.Curlx
-	transport *http.Transport
+	client *curlx.Curlx
 }
 
 // NewConnectionPoolManager 创建连接池管理器
@@ -31,8 +28,7 @@ func NewConnectionPoolManager(opts ...curlx.Option) *ConnectionPoolManager {
 	client := curlx.NewCurlx(poolOpts...)
 
 	return &ConnectionPoolManager{
-		client:    client,
-		// transport: client.transport,
+		client: client,
 	}
 }
 
@@ -53,37 +49,23 @@ func WithConnectionPoolSettings(
 
 // ConcurrentRequests 并发请求演示
 func (cpm *ConnectionPoolManager) ConcurrentRequests(urls []string) {
-	var wg sync.WaitGroup
-	results := make(chan string, len(urls))
+	specs := make([]curlx.RequestSpec, 0, len(urls))
+	for _, url := range urls {
+		specs = append(specs, curlx.RequestSpec{
+			Params: []curlx.Param{
+				curlx.SetParamsUrl(url),
+				curlx.SetParamsMethod(curlx.MethodGet),
+			},
+		})
+	}
 
-	startTime := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-	// 并发执行多个请求
-	for i, url := range urls {
-		wg.Add(1)
-		go func(index int, targetURL string) {
-			defer wg.Done()
-
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-
-			start := time.Now()
-			response, err := cpm.client.Get(ctx, targetURL)
-			duration := time.Since(start)
-
-			if err != nil {
-				results <- fmt.Sprintf("Request %d to %s failed: %v (took %v)",
-					index, targetURL, err, duration)
-			} else {
-				results <- fmt.Sprintf("Request %d to %s succeeded: %d bytes (took %v)",
-					index, targetURL, len(response), duration)
-			}
-		}(i, url)
-	}
+	startTime := time.Now()
 
-	// 等待所有请求完成
-	wg.Wait()
-	close(results)
+	// 并发执行多个请求，结果顺序与urls一致
+	results := cpm.client.SendBatch(ctx, specs, len(urls))
 
 	totalDuration := time.Since(startTime)
 	fmt.Printf("=== 并发请求完成 ===\n")
@@ -91,8 +73,14 @@ func (cpm *ConnectionPoolManager) ConcurrentRequests(urls []string) {
 	fmt.Printf("平均每个请求: %v\n", totalDuration/time.Duration(len(urls)))
 
 	// 输出结果
-	for result := range results {
-		fmt.Println(result)
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Printf("Request %d to %s failed: %v\n", result.Index, urls[result.Index], result.Err)
+			continue
+		}
+		body, _ := result.Response.GetBody()
+		fmt.Printf("Request %d to %s succeeded: status %d, %d bytes\n",
+			result.Index, urls[result.Index], result.Response.GetStatusCode(), len(body))
 	}
 
 	// 输出连接池状态
@@ -101,12 +89,17 @@ func (cpm *ConnectionPoolManager) ConcurrentRequests(urls []string) {
 
 // PrintPoolStats 打印连接池统计信息
 func (cpm *ConnectionPoolManager) PrintPoolStats() {
-	// stats := cpm.transport
+	stats := cpm.client.Stats()
 
 	fmt.Printf("\n=== 连接池统计 ===\n")
-	// fmt.Printf("当前空闲连接数: %d\n", stats.IdleConnCount())
-	// fmt.Printf("总连接数: %d\n", stats.TotalConnCount())
-	// fmt.Printf("等待队列长度: %d\n", stats.WaitQueueLength())
+	fmt.Printf("进行中请求数: %d\n", stats.InFlight)
+	fmt.Printf("新建连接数: %d\n", stats.NewConns)
+	fmt.Printf("复用连接数: %d\n", stats.ReusedConns)
+	fmt.Printf("拨号失败数: %d\n", stats.DialErrors)
+	fmt.Printf("TLS握手失败数: %d\n", stats.TLSErrors)
+	for host, hs := range stats.Hosts {
+		fmt.Printf("%s 活跃连接数: %d 空闲连接数: %d\n", host, hs.Active, hs.Idle)
+	}
 }
 
 // ReuseExample 连接复用示例
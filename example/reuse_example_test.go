@@ -91,7 +91,6 @@ func TestPersistentConnection(t *testing.T) {
 
 	manager := &ConnectionPoolManager{
 		client: client,
-		// transport: client.transport,
 	}
 
 	fmt.Println("=== 持久连接测试 ===")
@@ -134,8 +133,7 @@ func Example_connectionReuse() {
 
 	// 3. 查看连接池状态
 	manager := &ConnectionPoolManager{
-		client:    client,
-		// transport: client.transport,
+		client: client,
 	}
 	manager.PrintPoolStats()
 
@@ -143,7 +141,10 @@ func Example_connectionReuse() {
 	// 首次请求成功: [字节数]
 	// 第二次请求成功: [字节数]
 	// === 连接池统计 ===
-	// 当前空闲连接数: 1
-	// 总连接数: 1
-	// 等待队列长度: 0
+	// 进行中请求数: 0
+	// 新建连接数: 1
+	// 复用连接数: 1
+	// 拨号失败数: 0
+	// TLS握手失败数: 0
+	// httpbin.org 活跃连接数: 0 空闲连接数: 1
 }
@@ -0,0 +1,540 @@
+package curlx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR 1.2 格式，见 http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings 各阶段耗时(毫秒)，-1表示不适用
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+/**
+ * 读取HAR文件
+ */
+func LoadHAR(path string) (*HAR, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	har := &HAR{}
+	if err := json.Unmarshal(b, har); err != nil {
+		return nil, err
+	}
+	return har, nil
+}
+
+// HARRecorder 将经过的请求和响应记录为HAR
+type HARRecorder struct {
+	path   string
+	redact RedactRules
+
+	mu      sync.Mutex
+	entries []HAREntry
+}
+
+/**
+ * 创建HAR记录器，按默认规则脱敏
+ * 请求头、响应头、URL参数，以及JSON和url编码表单格式的请求/响应Body均会脱敏
+ * @param path 调用Save时写入的文件
+ */
+func NewHARRecorder(path string) *HARRecorder {
+	return &HARRecorder{
+		path:   path,
+		redact: DefaultRedactRules(),
+	}
+}
+
+/**
+ * 设置脱敏规则
+ */
+func (r *HARRecorder) SetRedact(rules RedactRules) *HARRecorder {
+	r.redact = rules
+	return r
+}
+
+/**
+ * 作为中间件使用，WithOptionMiddleware(recorder.Middleware())
+ * 响应Body读取完成或关闭时写入记录
+ */
+func (r *HARRecorder) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			timing := newRequestTiming()
+			started := time.Now()
+			req = req.WithContext(timing.withTrace(req.Context()))
+
+			var reqBody []byte
+			if req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					reqBody, _ = io.ReadAll(body)
+					body.Close()
+				}
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				timing.finish()
+				r.add(r.entry(started, req, reqBody, nil, nil, timing, err))
+				return nil, err
+			}
+
+			buf := &bytes.Buffer{}
+			resp.Body = &hookBody{
+				ReadCloser: teeReadCloser{Reader: io.TeeReader(resp.Body, buf), Closer: resp.Body},
+				onDone: func(int64) {
+					timing.finish()
+					r.add(r.entry(started, req, reqBody, resp, buf.Bytes(), timing, nil))
+				},
+			}
+			return resp, nil
+		})
+	}
+}
+
+/**
+ * 已记录的条目
+ */
+func (r *HARRecorder) Entries() []HAREntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]HAREntry{}, r.entries...)
+}
+
+/**
+ * 写入HAR文件
+ */
+func (r *HARRecorder) Save() error {
+	b, err := json.MarshalIndent(r.HAR(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, b, 0o644)
+}
+
+/**
+ * 当前记录的HAR
+ */
+func (r *HARRecorder) HAR() *HAR {
+	return &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "curlx", Version: Version},
+		Entries: r.Entries(),
+	}}
+}
+
+func (r *HARRecorder) add(e HAREntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+}
+
+func (r *HARRecorder) entry(started time.Time, req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, timing *requestTiming, err error) HAREntry {
+	t := timing.snapshot()
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+	timings := HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	if t.DNSLookup > 0 {
+		timings.DNS = ms(t.DNSLookup)
+	}
+	if t.TCPConnect > 0 {
+		timings.Connect = ms(t.TCPConnect + t.TLSHandshake)
+	}
+	if t.TLSHandshake > 0 {
+		timings.SSL = ms(t.TLSHandshake)
+	}
+	if wait := t.FirstByte - t.DNSLookup - t.TCPConnect - t.TLSHandshake; wait > 0 {
+		timings.Wait = ms(wait)
+	}
+	timings.Receive = ms(t.ContentTransfer)
+
+	reqURL := r.redact.RedactURL(req.URL.String())
+	query := url.Values{}
+	if u, parseErr := url.Parse(reqURL); parseErr == nil {
+		query = u.Query()
+	}
+
+	e := HAREntry{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Time:            ms(t.Total),
+		Request: HARRequest{
+			Method:      req.Method,
+			URL:         reqURL,
+			HTTPVersion: req.Proto,
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harHeaders(r.redact.RedactHeaders(req.Header)),
+			QueryString: harQuery(query),
+			HeadersSize: -1,
+			BodySize:    int64(len(reqBody)),
+		},
+		Timings: timings,
+	}
+	if host, _, splitErr := net.SplitHostPort(t.RemoteAddr); splitErr == nil {
+		e.ServerIPAddress = host
+	}
+	if len(reqBody) > 0 {
+		e.Request.PostData = &HARPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(r.redact.redactBody(reqBody, req.Header.Get("Content-Type"))),
+		}
+	}
+	// 脱敏的Cookie
+	for i := range e.Request.Cookies {
+		if len(r.redact.Cookies) == 0 || containsFold(r.redact.Cookies, e.Request.Cookies[i].Name) {
+			e.Request.Cookies[i].Value = r.redact.mask()
+		}
+	}
+
+	if err != nil {
+		e.Comment = err.Error()
+		e.Response = HARResponse{
+			Cookies:     []HARNameValue{},
+			Headers:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+		return e
+	}
+
+	e.Response = HARResponse{
+		Status:      resp.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode))),
+		HTTPVersion: resp.Proto,
+		Cookies:     []HARNameValue{},
+		Headers:     harHeaders(r.redact.RedactHeaders(resp.Header)),
+		Content: HARContent{
+			Size:     int64(len(respBody)),
+			MimeType: resp.Header.Get("Content-Type"),
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    int64(len(respBody)),
+	}
+	if utf8.Valid(respBody) {
+		e.Response.Content.Text = string(r.redact.redactBody(respBody, resp.Header.Get("Content-Type")))
+	} else {
+		e.Response.Content.Text = base64.StdEncoding.EncodeToString(respBody)
+		e.Response.Content.Encoding = "base64"
+	}
+	return e
+}
+
+/**
+ * 按内容类型脱敏Body，url编码表单按字段名，其他内容按JSON字段处理
+ */
+func (r RedactRules) redactBody(body []byte, contentType string) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if strings.EqualFold(mediaType, string(ContentTypeUrlEncoded)) {
+		return r.redactForm(body)
+	}
+	return r.RedactJSON(body)
+}
+
+func harHeaders(h http.Header) []HARNameValue {
+	out := []HARNameValue{}
+	for _, k := range sortedKeys(h) {
+		for _, v := range h[k] {
+			out = append(out, HARNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+func harQuery(q url.Values) []HARNameValue {
+	out := []HARNameValue{}
+	for _, k := range sortedKeys(q) {
+		for _, v := range q[k] {
+			out = append(out, HARNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+func harCookies(cookies []*http.Cookie) []HARNameValue {
+	out := []HARNameValue{}
+	for _, c := range cookies {
+		out = append(out, HARNameValue{Name: c.Name, Value: c.Value})
+	}
+	return out
+}
+
+// teeReadCloser 读取时复制内容，关闭原始Body
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ErrHARNoMatch HAR中没有匹配的请求
+var ErrHARNoMatch = errors.New("curlx: no matching HAR entry")
+
+// HARReplay 按HAR记录返回响应，不发出真实请求
+type HARReplay struct {
+	redact RedactRules
+
+	mu      sync.Mutex
+	entries []HAREntry
+	used    map[int]bool
+}
+
+/**
+ * 从HAR文件创建回放
+ */
+func NewHARReplay(path string) (*HARReplay, error) {
+	har, err := LoadHAR(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewHARReplayFrom(har), nil
+}
+
+func NewHARReplayFrom(har *HAR) *HARReplay {
+	return &HARReplay{
+		redact:  DefaultRedactRules(),
+		entries: har.Log.Entries,
+		used:    map[int]bool{},
+	}
+}
+
+/**
+ * 设置脱敏规则，需要与记录时 HARRecorder 使用的规则一致
+ */
+func (r *HARReplay) SetRedact(rules RedactRules) *HARReplay {
+	r.redact = rules
+	return r
+}
+
+/**
+ * 按方法、URL、Body匹配记录
+ * 记录和请求都按脱敏规则处理后再比较，脱敏的值不参与匹配
+ * 同一请求有多条记录时按顺序返回，用完后重复返回最后一条
+ * multipart请求的boundary每次不同，只匹配方法和URL
+ */
+func (r *HARReplay) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, e := range r.entries {
+		if !r.match(e, req, body) {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrHARNoMatch, req.Method, req.URL)
+	}
+	r.used[match] = true
+
+	e := r.entries[match]
+	// 记录的是请求失败
+	if e.Response.Status == 0 {
+		return nil, errors.New(e.Comment)
+	}
+	return harResponse(e, req)
+}
+
+/**
+ * 作为中间件使用，fallback为true时未匹配的请求继续发出
+ */
+func (r *HARReplay) Middleware(fallback bool) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !fallback {
+				return r.RoundTrip(req)
+			}
+			// 保留Body用于继续请求
+			var body []byte
+			if req.Body != nil {
+				body, _ = io.ReadAll(req.Body)
+				req.Body.Close()
+			}
+			clone := req.Clone(req.Context())
+			clone.Body = io.NopCloser(bytes.NewReader(body))
+			resp, err := r.RoundTrip(clone)
+			if errors.Is(err, ErrHARNoMatch) {
+				req.Body = io.NopCloser(bytes.NewReader(body))
+				return next.RoundTrip(req)
+			}
+			return resp, err
+		})
+	}
+}
+
+func (r *HARReplay) match(e HAREntry, req *http.Request, body []byte) bool {
+	if !strings.EqualFold(e.Request.Method, req.Method) {
+		return false
+	}
+	if normalizeURL(r.redact.RedactURL(e.Request.URL)) != normalizeURL(r.redact.RedactURL(req.URL.String())) {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "multipart/") {
+		return true
+	}
+	recorded := []byte{}
+	if e.Request.PostData != nil {
+		recorded = r.redact.redactBody([]byte(e.Request.PostData.Text), e.Request.PostData.MimeType)
+	}
+	return bodyEqual(recorded, r.redact.redactBody(body, req.Header.Get("Content-Type")))
+}
+
+/**
+ * URL规范化，参数按名称排序
+ */
+func normalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.RawQuery = u.Query().Encode()
+	u.Fragment = ""
+	return u.String()
+}
+
+/**
+ * Body比较，JSON忽略格式和字段顺序
+ */
+func bodyEqual(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return bytes.Equal(ja, jb)
+}
+
+func harResponse(e HAREntry, req *http.Request) (*http.Response, error) {
+	body := []byte(e.Response.Content.Text)
+	if e.Response.Content.Encoding == "base64" {
+		b, err := base64.StdEncoding.DecodeString(e.Response.Content.Text)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	header := http.Header{}
+	for _, h := range e.Response.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	// 记录的Body与响应头一致(未解压时保留Content-Encoding)，只需修正长度
+	header.Set("Content-Length", fmt.Sprint(len(body)))
+
+	proto := e.Response.HTTPVersion
+	major, minor, ok := http.ParseHTTPVersion(proto)
+	if !ok {
+		proto, major, minor = "HTTP/1.1", 1, 1
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Response.Status, e.Response.StatusText),
+		StatusCode:    e.Response.Status,
+		Proto:         proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
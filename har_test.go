@@ -0,0 +1,155 @@
+package curlx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHARRecordAndReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "sid=secret")
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"created":true}`))
+			return
+		}
+		w.Write([]byte(`{"id":` + r.URL.Query().Get("id") + `}`))
+	}))
+
+	path := filepath.Join(t.TempDir(), "traffic.har")
+	recorder := NewHARRecorder(path)
+	c := NewCurlx(WithOptionMiddleware(recorder.Middleware()))
+
+	ctx := context.Background()
+	if _, err := c.Get(ctx, srv.URL+"/users?id=1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Send(ctx,
+		SetParamsUrl(srv.URL+"/users"),
+		SetParamsMethod(MethodPost),
+		SetParamsContentType(ContentTypeJson),
+		SetParamsBody([]byte(`{"name":"bob","age":1}`)),
+		SetParamsHeader("Authorization", "Bearer token"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	har, err := LoadHAR(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(har.Log.Entries) != 2 || har.Log.Version != "1.2" {
+		t.Fatalf("entries = %d version = %s", len(har.Log.Entries), har.Log.Version)
+	}
+	post := har.Log.Entries[1]
+	for _, h := range append(post.Request.Headers, post.Response.Headers...) {
+		if strings.Contains(h.Value, "token") || strings.Contains(h.Value, "secret") {
+			t.Fatalf("header %s not redacted: %s", h.Name, h.Value)
+		}
+	}
+	if post.Response.Content.Text != `{"created":true}` || post.Timings.Wait <= 0 {
+		t.Fatalf("post entry = %+v", post)
+	}
+
+	// 服务已关闭，从HAR回放
+	replay, err := NewHARReplay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = NewCurlx(WithOptionMiddleware(replay.Middleware(false)))
+	body, err := c.Get(ctx, srv.URL+"/users?id=1")
+	if err != nil || string(body) != `{"id":1}` {
+		t.Fatalf("replay GET body = %s err = %v", body, err)
+	}
+	// JSON字段顺序不同也能匹配
+	body, err = c.PostJson(ctx, srv.URL+"/users", `{"age":1,"name":"bob"}`)
+	if err != nil || string(body) != `{"created":true}` {
+		t.Fatalf("replay POST body = %s err = %v", body, err)
+	}
+	if _, err := c.Get(ctx, srv.URL+"/users?id=2"); !errors.Is(err, ErrHARNoMatch) {
+		t.Fatalf("err = %v, want ErrHARNoMatch", err)
+	}
+}
+
+func TestHARRedaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"resp-secret","id":1}`))
+	}))
+
+	path := filepath.Join(t.TempDir(), "traffic.har")
+	recorder := NewHARRecorder(path)
+	c := NewCurlx(WithOptionMiddleware(recorder.Middleware()))
+
+	ctx := context.Background()
+	if _, err := c.Send(ctx,
+		SetParamsUrl(srv.URL+"/users?id=1"),
+		SetParamsMethod(MethodGet),
+		SetParamsAuth(APIKeyAuth("api_key", "query-secret", APIKeyInQuery)),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.PostJson(ctx, srv.URL+"/login", `{"user":"bob","password":"body-secret"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Send(ctx,
+		SetParamsUrl(srv.URL+"/token"),
+		SetParamsMethod(MethodPost),
+		SetParamsContentType(ContentTypeUrlEncoded),
+		SetParamsBodyAny(map[string]any{"grant_type": "client_credentials", "client_secret": "form-secret"}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"query-secret", "body-secret", "form-secret", "resp-secret"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("HAR contains %q", secret)
+		}
+	}
+
+	// 回放时请求按相同规则脱敏后匹配
+	replay, err := NewHARReplay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = NewCurlx(WithOptionMiddleware(replay.Middleware(false)))
+	if _, err := c.Send(ctx,
+		SetParamsUrl(srv.URL+"/users?id=1"),
+		SetParamsMethod(MethodGet),
+		SetParamsAuth(APIKeyAuth("api_key", "other-secret", APIKeyInQuery)),
+	); err != nil {
+		t.Fatalf("replay GET err = %v", err)
+	}
+	if _, err := c.PostJson(ctx, srv.URL+"/login", `{"password":"body-secret","user":"bob"}`); err != nil {
+		t.Fatalf("replay POST err = %v", err)
+	}
+	if _, err := c.Send(ctx,
+		SetParamsUrl(srv.URL+"/token"),
+		SetParamsMethod(MethodPost),
+		SetParamsContentType(ContentTypeUrlEncoded),
+		SetParamsBodyAny(map[string]any{"grant_type": "client_credentials", "client_secret": "form-secret"}),
+	); err != nil {
+		t.Fatalf("replay form err = %v", err)
+	}
+	if _, err := c.PostJson(ctx, srv.URL+"/login", `{"password":"x","user":"alice"}`); !errors.Is(err, ErrHARNoMatch) {
+		t.Fatalf("err = %v, want ErrHARNoMatch", err)
+	}
+}
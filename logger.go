@@ -0,0 +1,136 @@
+package curlx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Level 日志级别，数值与 log/slog 保持一致
+type Level int
+
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+func (l Level) String() string {
+	switch {
+	case l < LevelInfo:
+		return "DEBUG"
+	case l < LevelWarn:
+		return "INFO"
+	case l < LevelError:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// Field 结构化日志字段
+type Field struct {
+	Key   string
+	Value any
+}
+
+// StructuredLogger 结构化日志接口
+type StructuredLogger interface {
+	Log(ctx context.Context, level Level, msg string, fields ...Field)
+}
+
+/**
+ * 设置结构化日志输出，设置后 WithOptionLog 不再生效
+ */
+func WithOptionStructuredLogger(l StructuredLogger) Option {
+	return func(options *ClientOptions) {
+		options.StructuredLogger = l
+	}
+}
+
+/**
+ * 设置日志级别，低于该级别的日志不输出，默认Info
+ */
+func WithOptionLogLevel(level Level) Option {
+	return func(options *ClientOptions) {
+		options.LogLevel = level
+	}
+}
+
+/**
+ * 发送请求时将请求ID写入指定的请求头，如 X-Request-Id
+ */
+func WithOptionRequestIDHeader(name string) Option {
+	return func(options *ClientOptions) {
+		options.RequestIDHeader = name
+	}
+}
+
+type requestIDKey struct{}
+
+/**
+ * 指定请求ID，未指定时每次请求自动生成
+ */
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+/**
+ * 获取ctx中的请求ID
+ */
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ensureRequestID ctx中没有请求ID时生成一个
+func ensureRequestID(ctx context.Context) context.Context {
+	if RequestIDFromContext(ctx) != "" {
+		return ctx
+	}
+	return WithRequestID(ctx, newRequestID())
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// printfLogger 将结构化日志适配到 OptionLogger
+type printfLogger struct {
+	logger OptionLogger
+}
+
+func (l printfLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	b := strings.Builder{}
+	b.WriteString(msg)
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+	}
+	if level >= LevelWarn {
+		l.logger.Errorf(ctx, "%s", b.String())
+	} else {
+		l.logger.Infof(ctx, "%s", b.String())
+	}
+}
+
+/**
+ * 输出日志，自动带上请求ID
+ */
+func (c *Curlx) log(ctx context.Context, level Level, msg string, fields ...Field) {
+	if level < c.opts.LogLevel {
+		return
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		fields = append([]Field{{Key: "request_id", Value: id}}, fields...)
+	}
+	c.logger.Log(ctx, level, msg, fields...)
+}
+
+func (c *Curlx) logEnabled(level Level) bool {
+	return level >= c.opts.LogLevel
+}
@@ -0,0 +1,31 @@
+//go:build go1.21
+
+package curlx
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogLogger 将结构化日志输出到 log/slog
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+/**
+ * 使用 log/slog 输出日志，l为nil时使用 slog.Default()
+ */
+func NewSlogLogger(l *slog.Logger) StructuredLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return slogLogger{logger: l}
+}
+
+func (l slogLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	l.logger.LogAttrs(ctx, slog.Level(level), msg, attrs...)
+}
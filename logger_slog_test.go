@@ -0,0 +1,22 @@
+//go:build go1.21
+
+package curlx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSlogLogger(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	logger.Log(context.Background(), LevelWarn, "hello", Field{Key: "status", Value: 500})
+
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "msg=hello") || !strings.Contains(out, "status=500") {
+		t.Fatalf("unexpected slog output: %s", out)
+	}
+}
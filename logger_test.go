@@ -0,0 +1,121 @@
+package curlx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *captureLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "%s %s", level, msg)
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+	}
+	l.entries = append(l.entries, b.String())
+}
+
+func (l *captureLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.entries, "\n")
+}
+
+func TestStructuredLoggerRedaction(t *testing.T) {
+	var requestID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = r.Header.Get("X-Request-Id")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	logger := &captureLogger{}
+	c := NewCurlx(
+		WithOptionStructuredLogger(logger),
+		WithOptionLogLevel(LevelDebug),
+		WithOptionRequestIDHeader("X-Request-Id"),
+	)
+	ctx := WithRequestID(context.Background(), "req-1")
+	_, err := c.Send(ctx,
+		SetParamsUrl(srv.URL+"/?access_token=abc123&page=1"),
+		SetParamsMethod(MethodPost),
+		SetParamsContentType(ContentTypeJson),
+		SetParamsBody([]byte(`{"user":"bob","password":"hunter2"}`)),
+		SetParamsHeader("Authorization", "Bearer secret-token"),
+		SetCookie("session", "cookie-value"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := logger.String()
+	for _, leaked := range []string{"abc123", "hunter2", "secret-token", "cookie-value"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("log leaks %q:\n%s", leaked, out)
+		}
+	}
+	for _, want := range []string{"request_id=req-1", "page=1", `"user":"bob"`, "DEBUG curlx.sendExec params", "INFO curlx.sendExec response"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+	if requestID != "req-1" {
+		t.Errorf("X-Request-Id = %q, want req-1", requestID)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	logger := &captureLogger{}
+	c := NewCurlx(WithOptionStructuredLogger(logger), WithOptionLogLevel(LevelWarn))
+	if _, err := c.Get(context.Background(), srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if out := logger.String(); out != "" {
+		t.Fatalf("unexpected logs below warn level:\n%s", out)
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	rules := DefaultRedactRules()
+	got := string(rules.RedactJSON([]byte(`{"a":[{"Token":"x"}],"n":1.50}`)))
+	want := `{"a":[{"Token":"******"}],"n":1.50}`
+	if got != want {
+		t.Fatalf("RedactJSON = %s, want %s", got, want)
+	}
+	if got := string(rules.RedactJSON([]byte("not json"))); got != "not json" {
+		t.Fatalf("RedactJSON changed non-json body: %s", got)
+	}
+}
+
+func TestRedactForm(t *testing.T) {
+	rules := DefaultRedactRules()
+	masked := url.QueryEscape(rules.mask())
+	cases := []struct{ body, want string }{
+		{"user=bob&password=secret", "user=bob&password=" + masked},
+		{"user=bob", "user=bob"},
+		// 解析失败的片段不输出原文
+		{"user=bob&password=%zz", "user=bob&password=" + masked},
+		{"user=bob&%zzpassword=secret", "user=bob&" + masked},
+		{"user=bob;password=secret", masked},
+	}
+	for _, c := range cases {
+		if got := string(rules.redactForm([]byte(c.body))); got != c.want {
+			t.Errorf("redactForm(%q) = %q, want %q", c.body, got, c.want)
+		}
+	}
+}
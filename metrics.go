@@ -0,0 +1,257 @@
+package curlx
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestMetrics 单次请求的指标数据
+type RequestMetrics struct {
+	Host         string
+	Method       string
+	StatusCode   int // 请求失败时为0
+	Duration     time.Duration
+	RequestSize  int64 // 请求Body字节数
+	ResponseSize int64 // 已读取的响应Body字节数
+	Err          error
+}
+
+// MetricsCollector 指标采集接口
+type MetricsCollector interface {
+	// 请求发出前调用
+	RequestStarted(host, method string)
+	// 请求结束(失败或响应Body读取完成/关闭)时调用
+	RequestFinished(m RequestMetrics)
+	// 请求重试时调用
+	RequestRetried(host, method string)
+}
+
+/**
+ * 状态码分类，如 2xx/4xx，请求失败为 error
+ */
+func statusClass(code int) string {
+	if code <= 0 {
+		return "error"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+var (
+	// DefaultLatencyBuckets 默认耗时分桶(秒)
+	DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets 默认Body大小分桶(字节)
+	DefaultSizeBuckets = []float64{100, 1e3, 1e4, 1e5, 1e6, 1e7}
+)
+
+// PrometheusMetrics 以Prometheus文本格式输出的指标采集器
+type PrometheusMetrics struct {
+	namespace      string
+	latencyBuckets []float64
+	sizeBuckets    []float64
+
+	mu           sync.Mutex
+	requests     map[string]float64
+	inFlight     map[string]float64
+	retries      map[string]float64
+	latency      map[string]*histogram
+	requestSize  map[string]*histogram
+	responseSize map[string]*histogram
+}
+
+type histogram struct {
+	labels  string
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+/**
+ * 创建Prometheus指标采集器
+ * @param namespace 指标名前缀，为空时使用 curlx
+ */
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	if namespace == "" {
+		namespace = "curlx"
+	}
+	return &PrometheusMetrics{
+		namespace:      namespace,
+		latencyBuckets: DefaultLatencyBuckets,
+		sizeBuckets:    DefaultSizeBuckets,
+		requests:       map[string]float64{},
+		inFlight:       map[string]float64{},
+		retries:        map[string]float64{},
+		latency:        map[string]*histogram{},
+		requestSize:    map[string]*histogram{},
+		responseSize:   map[string]*histogram{},
+	}
+}
+
+/**
+ * 设置耗时分桶(秒)，需在使用前设置
+ */
+func (p *PrometheusMetrics) SetLatencyBuckets(buckets []float64) *PrometheusMetrics {
+	p.latencyBuckets = sortedBuckets(buckets)
+	return p
+}
+
+/**
+ * 设置Body大小分桶(字节)，需在使用前设置
+ */
+func (p *PrometheusMetrics) SetSizeBuckets(buckets []float64) *PrometheusMetrics {
+	p.sizeBuckets = sortedBuckets(buckets)
+	return p
+}
+
+func sortedBuckets(buckets []float64) []float64 {
+	b := append([]float64{}, buckets...)
+	sort.Float64s(b)
+	return b
+}
+
+func (p *PrometheusMetrics) RequestStarted(host, method string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inFlight[formatLabels("host", host)]++
+}
+
+func (p *PrometheusMetrics) RequestFinished(m RequestMetrics) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	hostLabels := formatLabels("host", m.Host)
+	p.inFlight[hostLabels]--
+
+	p.requests[formatLabels("host", m.Host, "method", m.Method, "status_class", statusClass(m.StatusCode))]++
+
+	labels := formatLabels("host", m.Host, "method", m.Method)
+	p.observe(p.latency, p.latencyBuckets, labels, m.Duration.Seconds())
+	p.observe(p.requestSize, p.sizeBuckets, labels, float64(m.RequestSize))
+	if m.Err == nil {
+		p.observe(p.responseSize, p.sizeBuckets, labels, float64(m.ResponseSize))
+	}
+}
+
+func (p *PrometheusMetrics) RequestRetried(host, method string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.retries[formatLabels("host", host, "method", method)]++
+}
+
+func (p *PrometheusMetrics) observe(hs map[string]*histogram, buckets []float64, labels string, v float64) {
+	h, ok := hs[labels]
+	if !ok {
+		h = &histogram{
+			labels:  labels,
+			buckets: buckets,
+			counts:  make([]uint64, len(buckets)),
+		}
+		hs[labels] = h
+	}
+	h.observe(v)
+}
+
+/**
+ * 以Prometheus文本格式输出全部指标
+ */
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	b := &strings.Builder{}
+	p.writeValues(b, "requests_total", "counter", "Total number of HTTP requests.", p.requests)
+	p.writeValues(b, "requests_in_flight", "gauge", "Number of HTTP requests in flight.", p.inFlight)
+	p.writeValues(b, "request_retries_total", "counter", "Total number of HTTP request retries.", p.retries)
+	p.writeHistograms(b, "request_duration_seconds", "HTTP request latency in seconds.", p.latency)
+	p.writeHistograms(b, "request_size_bytes", "HTTP request body size in bytes.", p.requestSize)
+	p.writeHistograms(b, "response_size_bytes", "HTTP response body size in bytes.", p.responseSize)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+/**
+ * 作为 /metrics 接口使用
+ */
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+func (p *PrometheusMetrics) writeValues(b *strings.Builder, name, typ, help string, values map[string]float64) {
+	if len(values) == 0 {
+		return
+	}
+	name = p.namespace + "_" + name
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	for _, labels := range sortedKeys(values) {
+		fmt.Fprintf(b, "%s{%s} %s\n", name, labels, formatFloat(values[labels]))
+	}
+}
+
+func (p *PrometheusMetrics) writeHistograms(b *strings.Builder, name, help string, hs map[string]*histogram) {
+	if len(hs) == 0 {
+		return
+	}
+	name = p.namespace + "_" + name
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, labels := range sortedKeys(hs) {
+		h := hs[labels]
+		for i, bucket := range h.buckets {
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(bucket), h.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+/**
+ * 按 key1, value1, key2, value2 的顺序格式化标签
+ */
+func formatLabels(kv ...string) string {
+	parts := make([]string, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		parts = append(parts, kv[i]+"=\""+escapeLabelValue(kv[i+1])+"\"")
+	}
+	return strings.Join(parts, ",")
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
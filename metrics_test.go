@@ -0,0 +1,56 @@
+package curlx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	m := NewPrometheusMetrics("")
+	c := NewCurlx(WithOptionMetrics(m))
+	c.Get(context.Background(), srv.URL)
+	c.PostJson(context.Background(), srv.URL, `{"a":1}`)
+	c.Get(context.Background(), srv.URL+"/missing")
+
+	host, _ := url.Parse(srv.URL)
+	b := &strings.Builder{}
+	m.WriteTo(b)
+	out := b.String()
+
+	for _, want := range []string{
+		"# TYPE curlx_requests_total counter",
+		`curlx_requests_total{host="` + host.Host + `",method="GET",status_class="2xx"} 1`,
+		`curlx_requests_total{host="` + host.Host + `",method="GET",status_class="4xx"} 1`,
+		`curlx_requests_total{host="` + host.Host + `",method="POST",status_class="2xx"} 1`,
+		`curlx_requests_in_flight{host="` + host.Host + `"} 0`,
+		"# TYPE curlx_request_duration_seconds histogram",
+		`curlx_request_duration_seconds_count{host="` + host.Host + `",method="GET"} 2`,
+		`curlx_request_size_bytes_bucket{host="` + host.Host + `",method="POST",le="100"} 1`,
+		`curlx_response_size_bytes_sum{host="` + host.Host + `",method="POST"} 5`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	got := formatLabels("host", `a"b\c`, "method", "GET")
+	want := `host="a\"b\\c",method="GET"`
+	if got != want {
+		t.Fatalf("formatLabels = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,32 @@
+package curlx
+
+import "net/http"
+
+// RoundTripperFunc 函数形式的 http.RoundTripper
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// Middleware 包装底层传输，可在请求发出前后做处理，也可以不调用next直接返回响应
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+/**
+ * 添加传输中间件，按添加顺序由外到内执行
+ */
+func WithOptionMiddleware(m ...Middleware) Option {
+	return func(options *ClientOptions) {
+		options.Middlewares = append(options.Middlewares, m...)
+	}
+}
+
+/**
+ * 按顺序包装中间件，第一个中间件在最外层
+ */
+func chainMiddlewares(rt http.RoundTripper, ms []Middleware) http.RoundTripper {
+	for i := len(ms) - 1; i >= 0; i-- {
+		rt = ms[i](rt)
+	}
+	return rt
+}
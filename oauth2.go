@@ -0,0 +1,220 @@
+package curlx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认提前刷新Token的时间
+const defaultTokenExpiryDelta = 10 * time.Second
+
+// Token OAuth2 访问令牌
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresIn    int64     `json:"expires_in,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	Expiry       time.Time `json:"-"` // 为零值时不过期
+}
+
+// OAuth2Error Token接口返回的错误 RFC 6749 5.2
+type OAuth2Error struct {
+	StatusCode  int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuth2Error) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("oauth2: %s: %s (status %d)", e.Code, e.Description, e.StatusCode)
+	}
+	return fmt.Sprintf("oauth2: %s (status %d)", e.Code, e.StatusCode)
+}
+
+// OAuth2Config Token接口配置
+type OAuth2Config struct {
+	TokenURL       string
+	ClientID       string
+	ClientSecret   string
+	Scopes         []string
+	EndpointParams map[string]string // 额外的请求参数，如 audience
+	AuthInParams   bool              // 客户端凭证放在请求参数中，默认使用Basic认证
+	ExpiryDelta    time.Duration     // 提前刷新的时间，默认10秒
+}
+
+// TokenSource 获取并缓存OAuth2 Token，并发安全
+// 实现了 ChallengeAuthenticator，可通过 WithOptionAuth/SetParamsAuth 作为Bearer认证使用
+type TokenSource struct {
+	client       *Curlx
+	config       OAuth2Config
+	grantType    string
+	mu           sync.Mutex
+	token        *Token
+	refreshToken string
+	now          func() time.Time
+}
+
+/**
+ * client_credentials 授权
+ * @param c 用于请求Token接口
+ */
+func NewClientCredentialsTokenSource(c *Curlx, config OAuth2Config) *TokenSource {
+	return &TokenSource{client: c, config: config, grantType: "client_credentials", now: time.Now}
+}
+
+/**
+ * refresh_token 授权，服务端返回新的refresh_token时自动替换
+ */
+func NewRefreshTokenSource(c *Curlx, config OAuth2Config, refreshToken string) *TokenSource {
+	return &TokenSource{client: c, config: config, grantType: "refresh_token", refreshToken: refreshToken, now: time.Now}
+}
+
+/**
+ * 获取有效的Token，过期前自动刷新
+ */
+func (s *TokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.valid(s.token) {
+		return s.token, nil
+	}
+	token, err := s.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+/**
+ * 丢弃缓存的Token，下次使用时重新获取
+ */
+func (s *TokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = nil
+}
+
+func (s *TokenSource) Authenticate(req *http.Request) error {
+	token, err := s.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return nil
+}
+
+/**
+ * 401 invalid_token 时刷新Token并重发一次
+ */
+func (s *TokenSource) Challenge(req *http.Request, resp *http.Response) bool {
+	invalid := false
+	for _, v := range resp.Header.Values("WWW-Authenticate") {
+		if len(v) < 7 || !strings.EqualFold(v[:7], "Bearer ") {
+			continue
+		}
+		if parseAuthParams(v[7:])["error"] == "invalid_token" {
+			invalid = true
+		}
+	}
+	if !invalid {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 其他请求已经刷新过时直接使用新Token
+	if s.token != nil && resp.Request != nil && resp.Request.Header.Get("Authorization") == "Bearer "+s.token.AccessToken {
+		s.token = nil
+	}
+	return true
+}
+
+func (s *TokenSource) valid(t *Token) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	if t.Expiry.IsZero() {
+		return true
+	}
+	delta := s.config.ExpiryDelta
+	if delta <= 0 {
+		delta = defaultTokenExpiryDelta
+	}
+	return s.now().Add(delta).Before(t.Expiry)
+}
+
+/**
+ * 请求Token接口
+ */
+func (s *TokenSource) fetch(ctx context.Context) (*Token, error) {
+	form := map[string]string{"grant_type": s.grantType}
+	if s.grantType == "refresh_token" {
+		if s.refreshToken == "" {
+			return nil, errors.New("oauth2: refresh token is empty")
+		}
+		form["refresh_token"] = s.refreshToken
+	}
+	if len(s.config.Scopes) > 0 {
+		form["scope"] = strings.Join(s.config.Scopes, " ")
+	}
+	for k, v := range s.config.EndpointParams {
+		form[k] = v
+	}
+
+	// 使用请求级认证，避免客户端的认证作用于Token接口
+	var auth Authenticator = AuthenticatorFunc(func(*http.Request) error { return nil })
+	if s.config.AuthInParams {
+		form["client_id"] = s.config.ClientID
+		if s.config.ClientSecret != "" {
+			form["client_secret"] = s.config.ClientSecret
+		}
+	} else {
+		// RFC 6749 2.3.1 凭证先按 application/x-www-form-urlencoded 编码
+		auth = BasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+
+	resp := s.client.SendWithResponse(ctx,
+		SetParamsUrl(s.config.TokenURL),
+		SetParamsMethod(MethodPost),
+		SetParamsContentType(ContentTypeUrlEncoded),
+		SetParamsBodyAny(form),
+		SetParamsHeader("Accept", "application/json"),
+		SetParamsAuth(auth),
+	)
+	body, err := resp.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.GetStatusCode() != http.StatusOK {
+		oauthErr := &OAuth2Error{StatusCode: resp.GetStatusCode()}
+		if json.Unmarshal(body, oauthErr) != nil || oauthErr.Code == "" {
+			oauthErr.Code = "invalid_response"
+		}
+		return nil, oauthErr
+	}
+
+	token := &Token{}
+	if err := json.Unmarshal(body, token); err != nil {
+		return nil, fmt.Errorf("oauth2: parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("oauth2: server response missing access_token")
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = s.now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	if token.RefreshToken != "" && s.grantType == "refresh_token" {
+		s.refreshToken = token.RefreshToken
+	}
+	return token, nil
+}
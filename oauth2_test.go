@@ -0,0 +1,206 @@
+package curlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientCredentialsTokenSource(t *testing.T) {
+	var grants int32
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if r.Method != http.MethodPost || user != "app" || pass != "secret" ||
+			r.PostFormValue("grant_type") != "client_credentials" || r.PostFormValue("scope") != "read write" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_request"}`))
+			return
+		}
+		n := atomic.AddInt32(&grants, 1)
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprintf(w, `{"access_token":"tok-%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer tokenSrv.Close()
+
+	now := time.Now()
+	ts := NewClientCredentialsTokenSource(NewCurlx(), OAuth2Config{
+		TokenURL:     tokenSrv.URL,
+		ClientID:     "app",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+	ts.now = func() time.Time { return now }
+
+	// 并发获取只请求一次
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := ts.Token(context.Background())
+			if err != nil || token.AccessToken != "tok-1" {
+				t.Errorf("token = %+v, err = %v", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if grants != 1 {
+		t.Errorf("grants = %d, want 1", grants)
+	}
+
+	// 过期前提前刷新
+	now = now.Add(3600*time.Second - 5*time.Second)
+	token, err := ts.Token(context.Background())
+	if err != nil || token.AccessToken != "tok-2" {
+		t.Errorf("token = %+v, err = %v", token, err)
+	}
+}
+
+func TestTokenSourceRetryInvalidToken(t *testing.T) {
+	var grants int32
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&grants, 1)
+		fmt.Fprintf(w, `{"access_token":"tok-%d","expires_in":3600}`, n)
+	}))
+	defer tokenSrv.Close()
+
+	var calls int32
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		// 第一个Token被服务端吊销
+		if r.Header.Get("Authorization") != "Bearer tok-2" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer apiSrv.Close()
+
+	c := NewCurlx()
+	ts := NewClientCredentialsTokenSource(c, OAuth2Config{TokenURL: tokenSrv.URL, ClientID: "app", ClientSecret: "secret"})
+	c = NewCurlx(WithOptionAuth(ts))
+
+	body, err := c.Send(context.Background(), SetParamsUrl(apiSrv.URL), SetParamsMethod(MethodGet))
+	if err != nil || string(body) != "ok" {
+		t.Fatalf("body = %q, err = %v", body, err)
+	}
+	if grants != 2 || calls != 2 {
+		t.Errorf("grants = %d, calls = %d", grants, calls)
+	}
+
+	// 其他401不刷新
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	resp := c.SendWithResponse(context.Background(), SetParamsUrl(srv.URL), SetParamsMethod(MethodGet))
+	defer resp.Close()
+	if resp.GetStatusCode() != http.StatusUnauthorized || grants != 2 {
+		t.Errorf("status = %d, grants = %d", resp.GetStatusCode(), grants)
+	}
+}
+
+func TestRefreshTokenSource(t *testing.T) {
+	var mu sync.Mutex
+	current := "r1"
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.PostFormValue("grant_type") != "refresh_token" || r.PostFormValue("client_id") != "app" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_request"}`))
+			return
+		}
+		if r.PostFormValue("refresh_token") != current {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"refresh token revoked"}`))
+			return
+		}
+		// 每次刷新轮换refresh_token
+		next := current + "x"
+		fmt.Fprintf(w, `{"access_token":"a-%s","refresh_token":"%s","expires_in":60}`, current, next)
+		current = next
+	}))
+	defer tokenSrv.Close()
+
+	now := time.Now()
+	ts := NewRefreshTokenSource(NewCurlx(), OAuth2Config{TokenURL: tokenSrv.URL, ClientID: "app", AuthInParams: true}, "r1")
+	ts.now = func() time.Time { return now }
+
+	for _, want := range []string{"a-r1", "a-r1x"} {
+		token, err := ts.Token(context.Background())
+		if err != nil || token.AccessToken != want {
+			t.Fatalf("token = %+v, err = %v, want %s", token, err, want)
+		}
+		now = now.Add(time.Minute)
+	}
+
+	stale := NewRefreshTokenSource(NewCurlx(), OAuth2Config{TokenURL: tokenSrv.URL, ClientID: "app", AuthInParams: true}, "r1")
+	_, err := stale.Token(context.Background())
+	var oauthErr *OAuth2Error
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_grant" || oauthErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestTokenRequestRedactedInLogs(t *testing.T) {
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"SECRETACCESS","refresh_token":"SECRETNEXT","token_type":"Bearer"}`))
+	}))
+	defer tokenSrv.Close()
+
+	logger := &captureLogger{}
+	c := NewCurlx(WithOptionStructuredLogger(logger), WithOptionLogLevel(LevelDebug))
+	ts := NewRefreshTokenSource(c, OAuth2Config{
+		TokenURL:     tokenSrv.URL,
+		ClientID:     "app",
+		ClientSecret: "SUPERSECRET",
+		AuthInParams: true,
+	}, "SECRETREFRESH")
+	if _, err := ts.Token(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := logger.String()
+	if !strings.Contains(logs, "client_id") {
+		t.Fatalf("request body not logged:\n%s", logs)
+	}
+	for _, secret := range []string{"SUPERSECRET", "SECRETREFRESH", "SECRETACCESS", "SECRETNEXT"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("logs contain %q:\n%s", secret, logs)
+		}
+	}
+}
+
+func TestClientCredentialsEscaped(t *testing.T) {
+	const clientID, secret = "app:1", "p@ss:wörd+/ %"
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		// RFC 6749 2.3.1 服务端先解码再比较
+		user, _ = url.QueryUnescape(user)
+		pass, _ = url.QueryUnescape(pass)
+		if user != clientID || pass != secret {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"tok","token_type":"Bearer"}`))
+	}))
+	defer tokenSrv.Close()
+
+	ts := NewClientCredentialsTokenSource(NewCurlx(), OAuth2Config{TokenURL: tokenSrv.URL, ClientID: clientID, ClientSecret: secret})
+	token, err := ts.Token(context.Background())
+	if err != nil || token.AccessToken != "tok" {
+		t.Fatalf("token = %+v, err = %v", token, err)
+	}
+}
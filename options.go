@@ -2,17 +2,47 @@ package curlx
 
 import (
 	"context"
+	"crypto/tls"
 	"log"
+	"net/http"
+	"net/url"
 	"time"
 )
 
 type ClientOptions struct {
-	TimeOut             time.Duration
-	InsecureSkipVerify  bool
-	Logger              OptionLogger
-	LoggerLength        int // 日志输出长度
-	CertFingerprint     string // 证书指纹验证
-	
+	BaseURL               string        // 相对地址的基础地址
+	Headers               http.Header   // 默认请求头
+	Cookies               []http.Cookie // 默认Cookie
+	Query                 url.Values    // 默认查询参数
+	ContentType           ContentType   // 默认数据类型
+	UserAgent             string        // 默认User-Agent
+	TimeOut               time.Duration
+	InsecureSkipVerify    bool
+	TLSConfig             *tls.Config // 自定义TLS配置(CA证书、客户端证书等)
+	Logger                OptionLogger
+	StructuredLogger      StructuredLogger  // 结构化日志，优先于Logger
+	LogLevel              Level             // 日志级别
+	Redact                RedactRules       // 日志脱敏规则
+	RequestIDHeader       string            // 请求ID请求头
+	LoggerLength          int               // 日志输出长度
+	RequestBodyLogLength  int               // 请求Body日志输出长度，为0时使用LoggerLength
+	ResponseBodyLogLength int               // 响应Body日志输出长度，为0时使用LoggerLength
+	BodyLogFormat         BodyLogFormat     // 日志中JSON Body的输出格式
+	CertFingerprint       string            // 证书指纹验证
+	Metrics               MetricsCollector  // 指标采集
+	Tracer                Tracer            // 链路追踪
+	Propagator            Propagator        // 链路信息传递格式
+	Middlewares           []Middleware      // 传输中间件
+	Transport             http.RoundTripper // 自定义底层传输
+	HTTPClient            *http.Client      // 自定义 http.Client
+	NoRedirect            bool              // 不跟随重定向
+	Cache                 CacheStorage      // 响应缓存
+	DedupKey              DedupKeyFunc      // 合并相同请求的key
+	RateLimit             int64             // 客户端总限速 字节/秒
+	Auth                  Authenticator     // 默认的认证
+	Signer                Signer            // 默认的请求签名
+	Verifier              ResponseVerifier  // 默认的响应签名校验
+
 	// 连接池配置
 	MaxIdleConns        int
 	MaxIdleConnsPerHost int
@@ -24,7 +54,11 @@ func defaultOptions() ClientOptions {
 	return ClientOptions{
 		TimeOut:             time.Second * 120, // 默认超时120秒
 		Logger:              defaultLogger{},
+		LogLevel:            LevelInfo,
+		Redact:              DefaultRedactRules(),
 		LoggerLength:        100,
+		UserAgent:           DefaultUserAgent,
+		Propagator:          TraceContextPropagator{},
 		MaxIdleConns:        100,              // 默认连接池大小
 		MaxIdleConnsPerHost: 10,               // 每主机默认空闲连接数
 		MaxConnsPerHost:     50,               // 每主机最大连接数
@@ -34,6 +68,15 @@ func defaultOptions() ClientOptions {
 
 type Option func(*ClientOptions)
 
+/**
+ * 设置基础地址，请求的相对地址按它解析
+ */
+func WithOptionBaseURL(baseURL string) Option {
+	return func(options *ClientOptions) {
+		options.BaseURL = baseURL
+	}
+}
+
 /**
  * 设置超时时间
  */
@@ -45,9 +88,18 @@ func WithOptionTimeOut(t time.Duration) Option {
 
 // 添加证书指纹验证选项
 func WithOptionTLSPin(certFingerprint string) Option {
-    return func(options *ClientOptions) {
-        options.CertFingerprint = certFingerprint
-    }
+	return func(options *ClientOptions) {
+		options.CertFingerprint = certFingerprint
+	}
+}
+
+/**
+ * 设置TLS配置，如自定义CA证书、客户端证书
+ */
+func WithOptionTLSConfig(cfg *tls.Config) Option {
+	return func(options *ClientOptions) {
+		options.TLSConfig = cfg
+	}
 }
 
 /**
@@ -77,6 +129,15 @@ func WithOptionLoggerLength(length int) Option {
 	}
 }
 
+/**
+ * 设置指标采集
+ */
+func WithOptionMetrics(m MetricsCollector) Option {
+	return func(options *ClientOptions) {
+		options.Metrics = m
+	}
+}
+
 // 连接池配置选项
 func WithMaxIdleConns(maxIdleConns int) Option {
 	return func(options *ClientOptions) {
@@ -3,15 +3,27 @@ package curlx
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 type ClientParams struct {
 	Url         string
-	Method      Method // GET/POST/PUT/DELETE
+	Query       url.Values        // URL查询参数，追加到Url原有参数之后
+	PathParams  map[string]string // Url中 {name} 变量的值
+	Method      Method            // GET/POST/PUT/DELETE
 	Body        []byte
 	Headers     http.Header
 	Cookies     []http.Cookie
-	ContentType ContentType // FORM,JSON,XML
+	ContentType ContentType      // FORM,JSON,XML
+	Retry       RetryPolicy      // 重试策略
+	Progress    func(p Progress) // 上传/下载进度回调
+	RateLimit   int64            // 单个请求限速 字节/秒
+	Auth        Authenticator    // 请求的认证
+	Signer      Signer           // 请求签名
+	Verifier    ResponseVerifier // 响应签名校验
+
+	noTimeout bool // 不使用客户端的总超时，由ctx控制(下载)
 }
 
 func defaultParams() ClientParams {
@@ -22,14 +34,44 @@ func defaultParams() ClientParams {
 
 type Param func(*ClientParams)
 
+// clone 复制参数，避免处理请求时修改原参数
+func (p ClientParams) clone() ClientParams {
+	cp := p
+	if p.Headers != nil {
+		cp.Headers = p.Headers.Clone()
+	}
+	cp.Cookies = append([]http.Cookie(nil), p.Cookies...)
+	if p.PathParams != nil {
+		cp.PathParams = make(map[string]string, len(p.PathParams))
+		for k, v := range p.PathParams {
+			cp.PathParams[k] = v
+		}
+	}
+	if p.Query != nil {
+		cp.Query = make(url.Values, len(p.Query))
+		for k, v := range p.Query {
+			cp.Query[k] = append([]string(nil), v...)
+		}
+	}
+	return cp
+}
+
 func SetParamsAll(cp ClientParams) Param {
 	return func(param *ClientParams) {
 		param.Url = cp.Url
+		param.Query = cp.Query
+		param.PathParams = cp.PathParams
 		param.Method = cp.Method
 		param.Body = cp.Body
 		param.Headers = cp.Headers
 		param.Cookies = cp.Cookies
 		param.ContentType = cp.ContentType
+		param.Retry = cp.Retry
+		param.Progress = cp.Progress
+		param.RateLimit = cp.RateLimit
+		param.Auth = cp.Auth
+		param.Signer = cp.Signer
+		param.Verifier = cp.Verifier
 	}
 }
 
@@ -42,6 +84,18 @@ func SetParamsUrl(url string) Param {
 	}
 }
 
+/**
+ * 设置路径参数，替换Url中的 {name}，值会被转义
+ */
+func SetParamsPathParam(name, value string) Param {
+	return func(param *ClientParams) {
+		if param.PathParams == nil {
+			param.PathParams = map[string]string{}
+		}
+		param.PathParams[name] = value
+	}
+}
+
 /**
  * 设置方法
  */
@@ -202,6 +256,25 @@ func SetParamsContentType(t ContentType) Param {
 	}
 }
 
+/**
+ * 设置重试次数和间隔，delay为0时按1s起指数退避
+ */
+func SetParamsRetry(count int, delay time.Duration) Param {
+	return func(param *ClientParams) {
+		param.Retry.Count = count
+		param.Retry.Delay = delay
+	}
+}
+
+/**
+ * 设置重试策略
+ */
+func SetParamsRetryPolicy(policy RetryPolicy) Param {
+	return func(param *ClientParams) {
+		param.Retry = policy
+	}
+}
+
 type FieldType string
 
 const (
@@ -0,0 +1,272 @@
+package curlx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 进度回调的最小间隔
+const progressInterval = 200 * time.Millisecond
+
+// 限速时每次读取的最大字节数
+const maxThrottleChunk = 32 << 10
+
+// ProgressDirection 传输方向
+type ProgressDirection int
+
+const (
+	ProgressUpload   ProgressDirection = iota // 上传请求Body
+	ProgressDownload                          // 下载响应Body
+)
+
+func (d ProgressDirection) String() string {
+	if d == ProgressUpload {
+		return "upload"
+	}
+	return "download"
+}
+
+// Progress 传输进度
+type Progress struct {
+	Direction   ProgressDirection
+	Transferred int64         // 已传输字节数
+	Total       int64         // 总字节数，未知时为-1
+	Rate        float64       // 传输速度 字节/秒
+	ETA         time.Duration // 预计剩余时间，总大小未知时为0
+}
+
+/**
+ * 设置请求的进度回调，上传和下载分别回调，按顺序调用
+ */
+func SetParamsProgress(fn func(p Progress)) Param {
+	return func(param *ClientParams) {
+		param.Progress = fn
+	}
+}
+
+/**
+ * 限制单个请求的上传和下载速度 字节/秒
+ */
+func SetParamsRateLimit(bytesPerSec int64) Param {
+	return func(param *ClientParams) {
+		param.RateLimit = bytesPerSec
+	}
+}
+
+/**
+ * 限制客户端所有请求的总速度 字节/秒，上传和下载共享
+ */
+func WithOptionRateLimit(bytesPerSec int64) Option {
+	return func(options *ClientOptions) {
+		options.RateLimit = bytesPerSec
+	}
+}
+
+// progressReporter 汇总进度并限制回调频率
+type progressReporter struct {
+	mu          sync.Mutex
+	fn          func(Progress)
+	direction   ProgressDirection
+	total       int64
+	transferred int64
+	base        int64 // 开始前已传输的字节数
+	start       time.Time
+	last        time.Time
+}
+
+func newProgressReporter(direction ProgressDirection, fn func(Progress)) *progressReporter {
+	return &progressReporter{fn: fn, direction: direction, total: -1, start: time.Now()}
+}
+
+func (r *progressReporter) begin(transferred, total int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transferred = transferred
+	r.base = transferred
+	r.total = total
+}
+
+func (r *progressReporter) add(n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transferred += n
+	if now := time.Now(); now.Sub(r.last) >= progressInterval {
+		r.last = now
+		r.report(now)
+	}
+}
+
+func (r *progressReporter) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report(time.Now())
+}
+
+func (r *progressReporter) report(now time.Time) {
+	if r.fn == nil {
+		return
+	}
+	p := Progress{Direction: r.direction, Transferred: r.transferred, Total: r.total}
+	if elapsed := now.Sub(r.start).Seconds(); elapsed > 0 {
+		p.Rate = float64(r.transferred-r.base) / elapsed
+	}
+	if p.Rate > 0 && r.total > r.transferred {
+		p.ETA = time.Duration(float64(r.total-r.transferred) / p.Rate * float64(time.Second))
+	}
+	r.fn(p)
+}
+
+type progressWriter struct {
+	w io.Writer
+	r *progressReporter
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.r.add(int64(n))
+	return n, err
+}
+
+// rateLimiter 令牌桶限速，桶容量为1秒的字节数
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: float64(bytesPerSec), tokens: float64(bytesPerSec), last: time.Now()}
+}
+
+/**
+ * 单次读取的最大字节数，避免一次读取超过桶容量
+ */
+func (l *rateLimiter) chunk() int {
+	n := int(l.rate / 10)
+	if n < 1 {
+		n = 1
+	}
+	if n > maxThrottleChunk {
+		n = maxThrottleChunk
+	}
+	return n
+}
+
+/**
+ * 消耗n个令牌，不足时预支并等待
+ */
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// transferBody 包装请求/响应Body，用于限速和进度回调
+type transferBody struct {
+	io.ReadCloser
+	ctx      context.Context
+	limiters []*rateLimiter
+	progress *progressReporter
+	once     sync.Once
+}
+
+func (b *transferBody) Read(p []byte) (int, error) {
+	for _, l := range b.limiters {
+		if size := l.chunk(); len(p) > size {
+			p = p[:size]
+		}
+	}
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		for _, l := range b.limiters {
+			if werr := l.wait(b.ctx, n); werr != nil {
+				return n, werr
+			}
+		}
+		if b.progress != nil {
+			b.progress.add(int64(n))
+		}
+	}
+	if err == io.EOF && b.progress != nil {
+		b.once.Do(b.progress.finish)
+	}
+	return n, err
+}
+
+/**
+ * 按请求参数和客户端配置包装Body，不需要时原样返回
+ */
+func (c *Curlx) wrapTransfer(ctx context.Context, body io.ReadCloser, total int64, direction ProgressDirection, p ClientParams, limiter *rateLimiter) io.ReadCloser {
+	if body == nil || body == http.NoBody {
+		return body
+	}
+	limiters := []*rateLimiter{}
+	if limiter != nil {
+		limiters = append(limiters, limiter)
+	}
+	if c.limiter != nil {
+		limiters = append(limiters, c.limiter)
+	}
+	if len(limiters) == 0 && p.Progress == nil {
+		return body
+	}
+	tb := &transferBody{ReadCloser: body, ctx: ctx, limiters: limiters}
+	if p.Progress != nil {
+		tb.progress = newProgressReporter(direction, p.Progress)
+		tb.progress.begin(0, total)
+	}
+	return tb
+}
+
+/**
+ * 包装请求Body，重定向时重新获取的Body同样包装
+ */
+func (c *Curlx) wrapRequestBody(request *http.Request, p ClientParams, limiter *rateLimiter) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return
+	}
+	ctx := request.Context()
+	total := request.ContentLength
+	if total <= 0 {
+		total = -1
+	}
+	request.Body = c.wrapTransfer(ctx, request.Body, total, ProgressUpload, p, limiter)
+	if getBody := request.GetBody; getBody != nil {
+		request.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return c.wrapTransfer(ctx, body, total, ProgressUpload, p, limiter), nil
+		}
+	}
+}
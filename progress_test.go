@@ -0,0 +1,97 @@
+package curlx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 64<<10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		w.Write(payload)
+	}))
+	defer srv.Close()
+
+	var (
+		mu     sync.Mutex
+		events = map[ProgressDirection][]Progress{}
+	)
+	c := NewCurlx()
+	_, err := c.Send(context.Background(),
+		SetParamsUrl(srv.URL),
+		SetParamsMethod(MethodPost),
+		SetParamsBody(payload),
+		SetParamsContentType(ContentTypeText),
+		SetParamsProgress(func(p Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+			events[p.Direction] = append(events[p.Direction], p)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, direction := range []ProgressDirection{ProgressUpload, ProgressDownload} {
+		list := events[direction]
+		if len(list) == 0 {
+			t.Fatalf("no %s events", direction)
+		}
+		last := list[len(list)-1]
+		if last.Transferred != int64(len(payload)) || last.Total != int64(len(payload)) {
+			t.Errorf("%s last = %+v", direction, last)
+		}
+		if last.ETA != 0 {
+			t.Errorf("%s ETA = %v, want 0 when done", direction, last.ETA)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	payload := bytes.Repeat([]byte("y"), 40000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer srv.Close()
+
+	for name, c := range map[string]struct {
+		client *Curlx
+		params []Param
+	}{
+		"request": {NewCurlx(), []Param{SetParamsRateLimit(20000)}},
+		"client":  {NewCurlx(WithOptionRateLimit(20000)), nil},
+	} {
+		start := time.Now()
+		ps := append([]Param{SetParamsUrl(srv.URL), SetParamsMethod(MethodGet)}, c.params...)
+		body, err := c.client.Send(context.Background(), ps...)
+		if err != nil || len(body) != len(payload) {
+			t.Fatalf("%s: len = %d, err = %v", name, len(body), err)
+		}
+		// 桶容量为1秒，剩余20000字节需要约1秒
+		if elapsed := time.Since(start); elapsed < 700*time.Millisecond {
+			t.Errorf("%s: elapsed = %v, want throttled", name, elapsed)
+		}
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	l := newRateLimiter(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx, 100); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v", err)
+	}
+	if newRateLimiter(0) != nil {
+		t.Error("zero rate should disable limiter")
+	}
+}
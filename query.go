@@ -0,0 +1,155 @@
+package curlx
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.yun.ink/pkg/convx"
+)
+
+/**
+ * 添加URL查询参数，适用于所有请求类型
+ * 多次设置同一个key时保留所有值
+ */
+func SetParamsQuery(key string, values ...string) Param {
+	return func(param *ClientParams) {
+		if param.Query == nil {
+			param.Query = url.Values{}
+		}
+		param.Query[key] = append(param.Query[key], values...)
+	}
+}
+
+/**
+ * 按map添加URL查询参数
+ * 值为切片或数组时添加多个同名参数，nil值忽略
+ */
+func SetParamsQueryMap(m map[string]any) Param {
+	return func(param *ClientParams) {
+		if param.Query == nil {
+			param.Query = url.Values{}
+		}
+		for _, k := range sortedKeys(m) {
+			addQueryValue(param.Query, k, reflect.ValueOf(m[k]))
+		}
+	}
+}
+
+/**
+ * 按结构体的 url 标签添加URL查询参数
+ * 标签格式为 `url:"name,omitempty"`，"-" 表示忽略，没有标签时使用字段名
+ * 匿名嵌入的结构体字段展开，传入的不是结构体时忽略
+ */
+func SetParamsQueryStruct(v any) Param {
+	return func(param *ClientParams) {
+		if param.Query == nil {
+			param.Query = url.Values{}
+		}
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		if rv.Kind() == reflect.Struct {
+			addQueryStruct(param.Query, rv)
+		}
+	}
+}
+
+func addQueryStruct(query url.Values, rv reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := rv.Field(i)
+
+		if field.Anonymous && name == "" {
+			for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				addQueryStruct(query, fv)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if opts == "omitempty" && fv.IsZero() {
+			continue
+		}
+		addQueryValue(query, name, fv)
+	}
+}
+
+/**
+ * 把值转换为字符串添加到参数，切片和数组展开为多个值
+ */
+func addQueryValue(query url.Values, key string, v reflect.Value) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		query.Add(key, t.Format(time.RFC3339))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			query.Add(key, convx.ToString(v.Interface()))
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			addQueryValue(query, key, v.Index(i))
+		}
+	case reflect.String:
+		query.Add(key, v.String())
+	case reflect.Bool:
+		query.Add(key, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		query.Add(key, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		query.Add(key, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		query.Add(key, strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()))
+	default:
+		query.Add(key, convx.ToString(v.Interface()))
+	}
+}
+
+/**
+ * 把查询参数追加到URL，URL中原有的参数保持不变
+ * 追加的参数按key排序，同名参数按添加顺序
+ */
+func withQuery(rawURL string, query url.Values) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if len(query) == 0 {
+		return rawURL, nil
+	}
+	encoded := query.Encode()
+	if u.RawQuery == "" {
+		u.RawQuery = encoded
+	} else if encoded != "" {
+		u.RawQuery += "&" + encoded
+	}
+	return u.String(), nil
+}
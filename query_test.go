@@ -0,0 +1,105 @@
+package curlx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestQueryParams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.RawQuery))
+	}))
+	defer srv.Close()
+
+	type Page struct {
+		Page int `url:"page,omitempty"`
+		Size int `url:"size,omitempty"`
+	}
+	type Filter struct {
+		Page
+		Tags    []string  `url:"tag"`
+		Keyword string    `url:"q,omitempty"`
+		Since   time.Time `url:"since,omitempty"`
+		Active  *bool     `url:"active"`
+		Secret  string    `url:"-"`
+		Plain   string
+		hidden  string
+	}
+	active := true
+
+	cases := []struct {
+		name string
+		url  string
+		ps   []Param
+		want string
+	}{
+		{
+			name: "post with query",
+			url:  srv.URL,
+			ps: []Param{
+				SetParamsMethod(MethodPost),
+				SetParamsBody([]byte(`{"a":1}`)),
+				SetParamsContentType(ContentTypeJson),
+				SetParamsQuery("z", "1"),
+				SetParamsQuery("id", "2", "3"),
+				SetParamsQuery("id", "1"),
+			},
+			want: "POST id=2&id=3&id=1&z=1",
+		},
+		{
+			name: "merge with url",
+			url:  srv.URL + "?b=x%20y&a=1",
+			ps: []Param{
+				SetParamsMethod(MethodGet),
+				SetParamsQueryMap(map[string]any{"c": []int{3, 1}, "d": nil, "e": 1.5}),
+			},
+			want: "GET b=x%20y&a=1&c=3&c=1&e=1.5",
+		},
+		{
+			name: "struct",
+			url:  srv.URL,
+			ps: []Param{
+				SetParamsMethod(MethodGet),
+				SetParamsQueryStruct(&Filter{
+					Page:   Page{Page: 2},
+					Tags:   []string{"go", "http"},
+					Active: &active,
+					Secret: "s",
+					Plain:  "p",
+					hidden: "h",
+				}),
+			},
+			want: "GET Plain=p&active=true&page=2&tag=go&tag=http",
+		},
+		{
+			name: "get json body",
+			url:  srv.URL + "?x=0",
+			ps: []Param{
+				SetParamsMethod(MethodGet),
+				SetParamsBody([]byte(`{"ids":[1,2],"name":"n"}`)),
+			},
+			want: "GET x=0&ids=1&ids=2&name=n",
+		},
+	}
+	for _, c := range cases {
+		ps := append([]Param{SetParamsUrl(c.url)}, c.ps...)
+		body, err := NewCurlx().Send(context.Background(), ps...)
+		if err != nil || string(body) != c.want {
+			t.Errorf("%s: body = %q, err = %v, want %q", c.name, body, err, c.want)
+		}
+	}
+}
+
+func TestQueryInCurl(t *testing.T) {
+	p := ClientParams{Url: "https://example.com/a?x=1", Method: MethodGet}
+	SetParamsQuery("q", "a b")(&p)
+	cmd := p.ToCurl()
+	want := "curl -X GET " + "'https://example.com/a?x=1&q=" + url.QueryEscape("a b") + "'"
+	if cmd != want {
+		t.Errorf("got %s, want %s", cmd, want)
+	}
+}
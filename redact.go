@@ -0,0 +1,210 @@
+package curlx
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RedactRules 日志脱敏规则，名称均不区分大小写
+type RedactRules struct {
+	Headers     []string // 需要脱敏的请求头/响应头
+	QueryParams []string // 需要脱敏的URL参数
+	JSONFields  []string // 需要脱敏的JSON字段(任意层级)
+	Cookies     []string // 需要脱敏的Cookie，为空时脱敏全部Cookie
+	Mask        string   // 替换后的内容
+}
+
+/**
+ * 默认脱敏规则
+ */
+func DefaultRedactRules() RedactRules {
+	return RedactRules{
+		Headers: []string{
+			"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie",
+			"X-Api-Key", "X-Auth-Token", "X-Access-Token",
+		},
+		QueryParams: []string{
+			"access_token", "refresh_token", "token", "api_key", "apikey",
+			"password", "secret", "client_secret", "signature", "sign",
+		},
+		JSONFields: []string{
+			"password", "passwd", "secret", "token", "access_token",
+			"refresh_token", "client_secret", "api_key", "private_key",
+		},
+		Mask: "******",
+	}
+}
+
+/**
+ * 设置日志脱敏规则
+ */
+func WithOptionRedact(rules RedactRules) Option {
+	return func(options *ClientOptions) {
+		options.Redact = rules
+	}
+}
+
+func (r RedactRules) mask() string {
+	if r.Mask == "" {
+		return "******"
+	}
+	return r.Mask
+}
+
+func containsFold(list []string, name string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 返回脱敏后的请求头副本
+ */
+func (r RedactRules) RedactHeaders(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, v := range h {
+		if containsFold(r.Headers, k) {
+			out[k] = []string{r.mask()}
+			continue
+		}
+		out[k] = append([]string{}, v...)
+	}
+	return out
+}
+
+/**
+ * 返回脱敏后的Cookie列表(name=value)
+ */
+func (r RedactRules) RedactCookies(cookies []http.Cookie) []string {
+	out := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		value := c.Value
+		if len(r.Cookies) == 0 || containsFold(r.Cookies, c.Name) {
+			value = r.mask()
+		}
+		out = append(out, c.Name+"="+value)
+	}
+	return out
+}
+
+/**
+ * 返回脱敏后的URL，同时隐藏URL中的密码
+ */
+func (r RedactRules) RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.RawQuery != "" && len(r.QueryParams) > 0 {
+		query := u.Query()
+		changed := false
+		for k, vs := range query {
+			if containsFold(r.QueryParams, k) {
+				for i := range vs {
+					vs[i] = r.mask()
+				}
+				changed = true
+			}
+		}
+		if changed {
+			u.RawQuery = query.Encode()
+		}
+	}
+	return u.Redacted()
+}
+
+/**
+ * 返回脱敏后的JSON，非JSON内容原样返回
+ */
+func (r RedactRules) RedactJSON(body []byte) []byte {
+	if len(r.JSONFields) == 0 {
+		return body
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return body
+	}
+
+	var v any
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return body
+	}
+	if !r.redactValue(v) {
+		return body
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+/**
+ * 返回脱敏后的url编码表单，按 JSONFields 匹配字段名
+ * 请求参数中的url编码表单以JSON保存，按JSON处理
+ */
+func (r RedactRules) redactForm(body []byte) []byte {
+	if len(r.JSONFields) == 0 {
+		return body
+	}
+	if looksLikeJSON(body) {
+		return r.RedactJSON(body)
+	}
+	// 逐个片段处理，未匹配的片段原样保留，解析失败的片段整体脱敏
+	mask := url.QueryEscape(r.mask())
+	pairs := strings.Split(string(body), "&")
+	changed := false
+	for i, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+		rawKey, rawValue, _ := strings.Cut(pair, "=")
+		key, keyErr := url.QueryUnescape(rawKey)
+		_, valueErr := url.QueryUnescape(rawValue)
+		switch {
+		case keyErr != nil || strings.Contains(pair, ";"):
+			pairs[i] = mask
+		case valueErr != nil || containsFold(r.JSONFields, key):
+			pairs[i] = rawKey + "=" + mask
+		default:
+			continue
+		}
+		changed = true
+	}
+	if !changed {
+		return body
+	}
+	return []byte(strings.Join(pairs, "&"))
+}
+
+func (r RedactRules) redactValue(v any) bool {
+	changed := false
+	switch value := v.(type) {
+	case map[string]any:
+		for k, child := range value {
+			if containsFold(r.JSONFields, k) {
+				value[k] = r.mask()
+				changed = true
+				continue
+			}
+			if r.redactValue(child) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, child := range value {
+			if r.redactValue(child) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
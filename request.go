@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 
 	"code.yun.ink/pkg/convx"
@@ -25,22 +27,101 @@ func (p *ClientParams) parseMethod() error {
 
 /**
  * 处理URL
+ * 填充路径参数，相对地址按 baseURL 解析，再追加查询参数
+ * 结果缺少协议或主机时返回错误
  */
-func (p *ClientParams) parseUrl() error {
-	_, err := url.Parse(p.Url)
+func (p *ClientParams) parseUrl(baseURL string) error {
+	rawURL, err := expandPathParams(p.Url, p.PathParams)
 	if err != nil {
 		return err
 	}
+	if baseURL != "" {
+		if rawURL, err = resolveURL(baseURL, rawURL); err != nil {
+			return err
+		}
+	}
+	rawURL, err = withQuery(rawURL, p.Query)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("curlx: invalid url %q: missing scheme or host", rawURL)
+	}
+	p.Url = rawURL
 	return nil
 }
 
+/**
+ * 用转义后的值替换路径中的 {name} 变量，查询参数和锚点部分不处理
+ */
+func expandPathParams(rawURL string, params map[string]string) (string, error) {
+	end := strings.IndexAny(rawURL, "?#")
+	if end < 0 {
+		end = len(rawURL)
+	}
+	path, rest := rawURL[:end], rawURL[end:]
+	if !strings.Contains(path, "{") {
+		return rawURL, nil
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			break
+		}
+		stop := strings.IndexByte(path[start:], '}')
+		if stop < 0 {
+			return "", fmt.Errorf("curlx: unclosed path param in %q", rawURL)
+		}
+		name := path[start+1 : start+stop]
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("curlx: missing path param %q", name)
+		}
+		b.WriteString(path[:start])
+		b.WriteString(url.PathEscape(value))
+		path = path[start+stop+1:]
+	}
+	b.WriteString(path)
+	return b.String() + rest, nil
+}
+
+/**
+ * 按 RFC 3986 把相对地址解析为绝对地址
+ * baseURL 的路径视为目录，如 https://api.example.com/v1 + users/1 = https://api.example.com/v1/users/1
+ * 以 / 开头的地址从主机根路径开始，绝对地址不受 baseURL 影响
+ */
+func resolveURL(baseURL, rawURL string) (string, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("curlx: invalid base url: %w", err)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+		if base.RawPath != "" {
+			base.RawPath += "/"
+		}
+	}
+	ref, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
 /**
  * 处理请求头Header
+ * @param userAgent 请求未设置User-Agent时使用
  */
-func (p *ClientParams) parseHeaders(r *http.Request) {
+func (p *ClientParams) parseHeaders(r *http.Request, userAgent string) {
 
-	if p.Headers.Get("User-Agent") == "" {
-		p.Headers.Add("User-Agent", string(UserAgentChrome))
+	if p.Headers.Get("User-Agent") == "" && userAgent != "" {
+		p.Headers.Set("User-Agent", userAgent)
 	}
 
 	r.Header = p.Headers
@@ -112,24 +193,9 @@ func (p *ClientParams) parseParams() (str io.Reader, err error) {
 		return strings.NewReader(values.Encode()), nil
 	default:
 		if p.Method == MethodGet {
-
-			m := map[string]any{}
-			if err = json.Unmarshal(p.Body, &m); err != nil {
-				return nil, err
-			}
-
-			url, err := url.Parse(p.Url) // 解析URL
-			if err != nil {
+			if err = p.parseBodyQuery(); err != nil {
 				return nil, err
 			}
-			query := url.Query()
-			for k, v := range m {
-				val := convx.ToString(v)
-				query[k] = append(query[k], val)
-			}
-			url.RawQuery = query.Encode()
-			p.Url = url.String()
-
 		} else {
 			return nil, errors.New("curlx 不支持的数据类型")
 		}
@@ -138,6 +204,28 @@ func (p *ClientParams) parseParams() (str io.Reader, err error) {
 	return
 }
 
+/**
+ * 未指定数据类型的GET请求，Body参数合并到URL
+ */
+func (p *ClientParams) parseBodyQuery() error {
+	m := map[string]any{}
+	if err := json.Unmarshal(p.Body, &m); err != nil {
+		return err
+	}
+
+	// 只追加Body中的参数，URL中原有的参数保持不变
+	query := url.Values{}
+	for _, k := range sortedKeys(m) {
+		addQueryValue(query, k, reflect.ValueOf(m[k]))
+	}
+	rawURL, err := withQuery(p.Url, query)
+	if err != nil {
+		return err
+	}
+	p.Url = rawURL
+	return nil
+}
+
 /**
  * 处理Cookie
  */
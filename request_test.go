@@ -0,0 +1,81 @@
+package curlx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseUrl(t *testing.T) {
+	cases := []struct {
+		name    string
+		base    string
+		url     string
+		path    map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "absolute", url: "https://a.example.com/x?y=1", want: "https://a.example.com/x?y=1"},
+		{name: "absolute ignores base", base: "https://b.example.com/v1", url: "https://a.example.com/x", want: "https://a.example.com/x"},
+		{name: "relative", base: "https://b.example.com/v1", url: "users", want: "https://b.example.com/v1/users"},
+		{name: "root relative", base: "https://b.example.com/v1/", url: "/health", want: "https://b.example.com/health"},
+		{name: "empty url", base: "https://b.example.com/v1/", want: "https://b.example.com/v1/"},
+		{
+			name: "path params",
+			base: "https://b.example.com/v1/",
+			url:  "users/{id}/orders/{orderId}?q={raw}",
+			path: map[string]string{"id": "a/b c", "orderId": "42"},
+			want: "https://b.example.com/v1/users/a%2Fb%20c/orders/42?q={raw}",
+		},
+		{name: "missing path param", url: "https://b.example.com/users/{id}", wantErr: true},
+		{name: "unclosed path param", url: "https://b.example.com/users/{id", path: map[string]string{"id": "1"}, wantErr: true},
+		{name: "relative without base", url: "/users", wantErr: true},
+		{name: "malformed", url: "http://[::1", wantErr: true},
+	}
+	for _, c := range cases {
+		p := ClientParams{Url: c.url, PathParams: c.path}
+		err := p.parseUrl(c.base)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got %s", c.name, p.Url)
+			}
+			continue
+		}
+		if err != nil || p.Url != c.want {
+			t.Errorf("%s: url = %s, err = %v, want %s", c.name, p.Url, err, c.want)
+		}
+	}
+}
+
+func TestBaseURLRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.EscapedPath() + "?" + r.URL.RawQuery))
+	}))
+	defer srv.Close()
+
+	c := NewCurlx(WithOptionBaseURL(srv.URL + "/api"))
+	body, err := c.Send(context.Background(),
+		SetParamsUrl("users/{id}"),
+		SetParamsPathParam("id", "7"),
+		SetParamsQuery("fields", "name"),
+		SetParamsMethod(MethodGet),
+	)
+	if err != nil || string(body) != "/api/users/7?fields=name" {
+		t.Errorf("body = %q, err = %v", body, err)
+	}
+}
+
+// GET请求的Body参数追加到URL，原有参数的顺序和编码保持不变
+func TestParseBodyQuery(t *testing.T) {
+	p := ClientParams{
+		Url:  "https://a.example.com/x?z=1&a=%7Braw%7D&z=0",
+		Body: []byte(`{"b":"x y","a":2}`),
+	}
+	if err := p.parseBodyQuery(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://a.example.com/x?z=1&a=%7Braw%7D&z=0&a=2&b=x+y"; p.Url != want {
+		t.Errorf("url = %s, want %s", p.Url, want)
+	}
+}
@@ -6,20 +6,25 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/tidwall/gjson"
 )
 
 // Response response object
 type Response struct {
-	response *http.Response
-	request  *http.Request
-	body     []byte
-	err      error
+	response    *http.Response
+	request     *http.Request
+	body        []byte
+	err         error
+	timing      *requestTiming
+	requestID   string
+	cacheStatus CacheStatus
+	verifier    ResponseVerifier
 }
 
 func (l *Response) Close() error {
-	if l.response.Body != nil {
+	if l.response != nil && l.response.Body != nil {
 		return l.response.Body.Close()
 	}
 	return nil
@@ -34,6 +39,16 @@ func (r *Response) GetResponse() *http.Response {
 	return r.response
 }
 
+// GetRequestID get request id used in logs
+func (r *Response) GetRequestID() string {
+	return r.requestID
+}
+
+// GetCacheStatus get whether the response was served from cache
+func (r *Response) GetCacheStatus() CacheStatus {
+	return r.cacheStatus
+}
+
 func (r *Response) GetError() error {
 	return r.err
 }
@@ -70,6 +85,14 @@ func (r *Response) GetBody() ([]byte, error) {
 	// close body
 	r.response.Body.Close()
 
+	// 校验响应签名
+	if r.verifier != nil {
+		if err := r.verifier.Verify(r.response, body); err != nil {
+			r.err = err
+			return nil, err
+		}
+	}
+
 	r.body = body
 	return body, err
 }
@@ -81,6 +104,14 @@ func (r Response) GetStatusCode() int {
 	return r.response.StatusCode
 }
 
+// GetTiming get request timing breakdown
+func (r *Response) GetTiming() Timing {
+	if r.timing == nil {
+		return Timing{}
+	}
+	return r.timing.snapshot()
+}
+
 // IsTimeout get if request is timeout
 func (r *Response) IsTimeout() bool {
 	if r.err == nil {
@@ -149,3 +180,32 @@ func (r *Response) HasHeader(name string) bool {
 
 	return false
 }
+
+// hookBody 响应Body包装，读取到EOF或关闭时回调一次并带上已读取的字节数
+type hookBody struct {
+	io.ReadCloser
+	read   int64
+	once   sync.Once
+	onDone func(read int64)
+}
+
+func (b *hookBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if err == io.EOF {
+		b.done()
+	}
+	return n, err
+}
+
+func (b *hookBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+func (b *hookBody) done() {
+	b.once.Do(func() {
+		b.onDone(b.read)
+	})
+}
@@ -0,0 +1,65 @@
+package curlx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy 请求重试策略
+type RetryPolicy struct {
+	Count    int           // 最大重试次数，0为不重试
+	Delay    time.Duration // 重试间隔，为0时按1s起指数退避
+	MaxDelay time.Duration // 指数退避的最大间隔，默认30s
+	// 判断是否需要重试，为空时网络错误及 408/429/500/502/503/504 重试
+	Condition func(resp *Response) bool
+}
+
+// 默认需要重试的状态码，与 curl --retry 一致
+var retryStatusCodes = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+func (r RetryPolicy) shouldRetry(resp Response) bool {
+	if r.Condition != nil {
+		return r.Condition(&resp)
+	}
+	if resp.err != nil {
+		// 主动取消的请求不再重试
+		return !errors.Is(resp.err, context.Canceled)
+	}
+	return retryStatusCodes[resp.GetStatusCode()]
+}
+
+/**
+ * 第attempt次重试前的等待时间，优先使用响应头Retry-After(秒)
+ */
+func (r RetryPolicy) delay(attempt int, resp Response) time.Duration {
+	maxDelay := r.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	if seconds, err := strconv.Atoi(resp.GetHeaderLine("Retry-After")); err == nil && seconds >= 0 {
+		if d := time.Duration(seconds) * time.Second; d < maxDelay {
+			return d
+		}
+		return maxDelay
+	}
+
+	if r.Delay > 0 {
+		return r.Delay
+	}
+	d := time.Second << uint(attempt)
+	if d <= 0 || d > maxDelay {
+		return maxDelay
+	}
+	return d
+}
@@ -0,0 +1,58 @@
+package curlx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	m := NewPrometheusMetrics("")
+	c := NewCurlx(WithOptionMetrics(m))
+	body, err := c.Send(context.Background(),
+		SetParamsUrl(srv.URL),
+		SetParamsMethod(MethodGet),
+		SetParamsRetry(3, time.Second),
+	)
+	if err != nil || string(body) != "ok" {
+		t.Fatalf("body = %q err = %v", body, err)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+
+	b := &strings.Builder{}
+	m.WriteTo(b)
+	if !strings.Contains(b.String(), `curlx_request_retries_total{host="`+strings.TrimPrefix(srv.URL, "http://")+`",method="GET"} 2`) {
+		t.Fatalf("retries not recorded:\n%s", b)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{}
+	if d := policy.delay(0, Response{}); d != time.Second {
+		t.Fatalf("delay(0) = %v", d)
+	}
+	if d := policy.delay(10, Response{}); d != 30*time.Second {
+		t.Fatalf("delay(10) = %v", d)
+	}
+	policy.Delay = 10 * time.Millisecond
+	if d := policy.delay(5, Response{}); d != 10*time.Millisecond {
+		t.Fatalf("fixed delay = %v", d)
+	}
+}
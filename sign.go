@@ -0,0 +1,322 @@
+package curlx
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 空Body的SHA256摘要
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Signer 请求签名
+// 在请求参数、请求头、Cookie和认证处理完成后、发送前调用，每次重试都会重新签名
+// 签名作用于发送的请求副本，只对原始请求的主机签名
+// payloadHash 为请求Body的SHA256摘要(十六进制)
+type Signer interface {
+	Sign(req *http.Request, payloadHash string) error
+}
+
+// ErrSignConflict 签名修改了认证设置的请求头，如同时使用 BearerAuth 和写入Authorization的签名
+var ErrSignConflict = errors.New("curlx: signer overwrote authentication header")
+
+// SignerFunc 函数形式的 Signer
+type SignerFunc func(req *http.Request, payloadHash string) error
+
+func (f SignerFunc) Sign(req *http.Request, payloadHash string) error {
+	return f(req, payloadHash)
+}
+
+/**
+ * 设置客户端默认的请求签名
+ */
+func WithOptionSigner(signer Signer) Option {
+	return func(options *ClientOptions) {
+		options.Signer = signer
+	}
+}
+
+/**
+ * 设置请求的签名，优先于客户端的签名
+ */
+func SetParamsSigner(signer Signer) Param {
+	return func(param *ClientParams) {
+		param.Signer = signer
+	}
+}
+
+/**
+ * 计算请求Body的SHA256摘要，需要在包装Body前调用
+ */
+func payloadHash(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return emptyPayloadHash, nil
+	}
+	if req.GetBody == nil {
+		return "", errors.New("curlx: request body cannot be read for signing")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+/**
+ * 签名请求
+ * @param hash 包装Body前计算的摘要，请求没有Body时(如重定向后改为GET)使用空Body的摘要
+ */
+func signRequest(signer Signer, req *http.Request, hash string) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		hash = emptyPayloadHash
+	}
+	if err := signer.Sign(req, hash); err != nil {
+		return fmt.Errorf("curlx: sign: %w", err)
+	}
+	return nil
+}
+
+// HMACSigner 通用的 HMAC-SHA256 规范请求签名
+// 待签名字符串为 "<Algorithm>\n" + hex(sha256(规范请求))，规范请求格式与 AWS SigV4 相同:
+//
+//	Method\nCanonicalURI\nCanonicalQuery\nCanonicalHeaders\nSignedHeaders\nPayloadHash
+//
+// 签名写入 Authorization: <Algorithm> Credential=<AccessKeyID>,SignedHeaders=<...>,Signature=<hex>
+// 按阿里云 ACS3-HMAC-SHA256 配置:
+//
+//	&HMACSigner{Algorithm: "ACS3-HMAC-SHA256", AccessKeyID: id, SecretKey: secret,
+//		DateHeader: "x-acs-date", NonceHeader: "x-acs-signature-nonce",
+//		ContentHashHeader: "x-acs-content-sha256", SignedHeaders: []string{"x-acs-*"}}
+type HMACSigner struct {
+	Algorithm         string   // 签名算法名称，默认 HMAC-SHA256
+	AccessKeyID       string   // 访问密钥ID
+	SecretKey         string   // 访问密钥
+	SignedHeaders     []string // 参与签名的请求头，以*结尾时按前缀匹配；host和content-type总是参与签名
+	DateHeader        string   // 写入签名时间(UTC, 2006-01-02T15:04:05Z)的请求头，为空不添加
+	NonceHeader       string   // 写入随机数的请求头，为空不添加
+	ContentHashHeader string   // 写入Body摘要的请求头，为空不添加
+
+	now   func() time.Time
+	nonce func() string
+}
+
+func (s *HMACSigner) Sign(req *http.Request, payloadHash string) error {
+	if s.AccessKeyID == "" || s.SecretKey == "" {
+		return errors.New("hmac signer: missing credentials")
+	}
+	algorithm := s.Algorithm
+	if algorithm == "" {
+		algorithm = "HMAC-SHA256"
+	}
+
+	patterns := append([]string{"host", "content-type"}, s.SignedHeaders...)
+	if s.DateHeader != "" {
+		now := time.Now
+		if s.now != nil {
+			now = s.now
+		}
+		req.Header.Set(s.DateHeader, now().UTC().Format("2006-01-02T15:04:05Z"))
+		patterns = append(patterns, s.DateHeader)
+	}
+	if s.NonceHeader != "" {
+		nonce := newCnonce
+		if s.nonce != nil {
+			nonce = s.nonce
+		}
+		req.Header.Set(s.NonceHeader, nonce())
+		patterns = append(patterns, s.NonceHeader)
+	}
+	if s.ContentHashHeader != "" {
+		req.Header.Set(s.ContentHashHeader, payloadHash)
+		patterns = append(patterns, s.ContentHashHeader)
+	}
+
+	canonical, signedHeaders := canonicalRequest(req, patterns, payloadHash, false)
+	stringToSign := algorithm + "\n" + sha256Hex(canonical)
+	signature := hex.EncodeToString(hmacSHA256([]byte(s.SecretKey), stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s,SignedHeaders=%s,Signature=%s",
+		algorithm, s.AccessKeyID, signedHeaders, signature))
+	return nil
+}
+
+// SigV4Signer AWS Signature Version 4 签名，也适用于S3兼容的对象存储
+type SigV4Signer struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // 临时凭证的Token，为空不添加
+	Region          string // 如 us-east-1
+	Service         string // 如 s3、iam
+
+	now func() time.Time
+}
+
+func (s *SigV4Signer) Sign(req *http.Request, payloadHash string) error {
+	if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return errors.New("sigv4: missing credentials")
+	}
+	if s.Region == "" || s.Service == "" {
+		return errors.New("sigv4: region and service are required")
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+	if s.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	// S3 的路径不做二次编码
+	patterns := []string{"host", "content-type", "content-md5", "x-amz-*"}
+	canonical, signedHeaders := canonicalRequest(req, patterns, payloadHash, s.Service != "s3")
+
+	scope := date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex(canonical)
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+/**
+ * 生成规范请求
+ * @param patterns 参与签名的请求头，以*结尾时按前缀匹配，不存在或为空的请求头不参与签名
+ * @param escapePath 路径是否再次编码
+ * @return 规范请求和参与签名的请求头列表(;分隔)
+ */
+func canonicalRequest(req *http.Request, patterns []string, payloadHash string, escapePath bool) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string][]string{}
+	if host != "" && matchHeader(patterns, "host") {
+		values["host"] = []string{host}
+	}
+	for name, vs := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "host" || !matchHeader(patterns, lower) {
+			continue
+		}
+		for _, v := range vs {
+			if v = strings.Join(strings.Fields(v), " "); v != "" {
+				values[lower] = append(values[lower], v)
+			}
+		}
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(name + ":" + strings.Join(values[name], ",") + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if escapePath {
+		path = uriEscape(path, false)
+	}
+
+	canonical := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	return canonical, signedHeaders
+}
+
+/**
+ * 规范查询字符串，按参数名和值排序，RFC 3986 编码
+ */
+func canonicalQuery(query url.Values) string {
+	pairs := []string{}
+	for k, vs := range query {
+		for _, v := range vs {
+			pairs = append(pairs, uriEscape(k, true)+"="+uriEscape(v, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func matchHeader(patterns []string, name string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(name, p[:len(p)-1]) {
+				return true
+			}
+		} else if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * RFC 3986 编码，只保留非保留字符
+ * @param escapeSlash 是否编码/
+ */
+func uriEscape(s string, escapeSlash bool) string {
+	const hexUpper = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !escapeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexUpper[c>>4])
+		b.WriteByte(hexUpper[c&15])
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,205 @@
+package curlx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// AWS SigV4 测试用例
+func TestSigV4Vectors(t *testing.T) {
+	signTime := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	cases := []struct {
+		name        string
+		method      string
+		url         string
+		contentType string
+		service     string
+		want        string
+	}{
+		{
+			name:    "get-vanilla",
+			method:  "GET",
+			url:     "https://example.amazonaws.com/",
+			service: "service",
+			want:    "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:    "get-vanilla-query-order-key-case",
+			method:  "GET",
+			url:     "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			service: "service",
+			want:    "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:        "iam-list-users",
+			method:      "GET",
+			url:         "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+			contentType: "application/x-www-form-urlencoded; charset=utf-8",
+			service:     "iam",
+			want:        "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest(c.method, c.url, nil)
+		if c.contentType != "" {
+			req.Header.Set("Content-Type", c.contentType)
+		}
+		s := &SigV4Signer{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			Region:          "us-east-1",
+			Service:         c.service,
+			now:             func() time.Time { return signTime },
+		}
+		if err := signRequest(s, req, emptyPayloadHash); err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("Authorization"); got != c.want {
+			t.Errorf("%s:\n got %s\nwant %s", c.name, got, c.want)
+		}
+	}
+}
+
+// 阿里云 openapi-util 的 ACS3-HMAC-SHA256 签名用例(Test_GetAuthorization)
+// 请求没有方法和Host，payloadHash 按原样参与签名
+func TestHMACSignerVector(t *testing.T) {
+	u, _ := url.Parse("?test=ok&empty=")
+	req := &http.Request{URL: u, Header: http.Header{}}
+	req.Header.Add("x-acs-test", "http")
+	req.Header.Add("x-acs-TEST", "https")
+	s := &HMACSigner{
+		Algorithm:     "ACS3-HMAC-SHA256",
+		AccessKeyID:   "acesskey",
+		SecretKey:     "secret",
+		SignedHeaders: []string{"x-acs-*"},
+	}
+	if err := s.Sign(req, "55e12e91650d2fec56ec74e1d3e4ddbfce2ef3a65890c2a19ecf88a307e76a23"); err != nil {
+		t.Fatal(err)
+	}
+	want := "ACS3-HMAC-SHA256 Credential=acesskey,SignedHeaders=x-acs-test,Signature=4ab59fffe3c5738ff8a2729f90cc04fe18b02a4b15b2102cbaf92f9ff3df2ea3"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestHMACSigner(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://ecs.example.com/api/v1/items?b=2&a=x%20y&a=1", strings.NewReader(`{"id":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Acs-Action", "RunInstances")
+	req.Header.Set("X-Other", "ignored")
+	s := &HMACSigner{
+		Algorithm:         "ACS3-HMAC-SHA256",
+		AccessKeyID:       "YourAccessKeyId",
+		SecretKey:         "YourAccessKeySecret",
+		DateHeader:        "x-acs-date",
+		NonceHeader:       "x-acs-signature-nonce",
+		ContentHashHeader: "x-acs-content-sha256",
+		SignedHeaders:     []string{"x-acs-*"},
+		now:               func() time.Time { return time.Date(2023, 10, 26, 10, 22, 32, 0, time.UTC) },
+		nonce:             func() string { return "3156853299f313e23d1673dc12e1703d" },
+	}
+	hash, err := payloadHash(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signRequest(s, req, hash); err != nil {
+		t.Fatal(err)
+	}
+
+	// 规范请求:
+	// POST
+	// /api/v1/items
+	// a=1&a=x%20y&b=2
+	// content-type:application/json
+	// host:ecs.example.com
+	// x-acs-action:RunInstances
+	// x-acs-content-sha256:<Body摘要>
+	// x-acs-date:2023-10-26T10:22:32Z
+	// x-acs-signature-nonce:3156853299f313e23d1673dc12e1703d
+	//
+	// content-type;host;x-acs-action;x-acs-content-sha256;x-acs-date;x-acs-signature-nonce
+	// <Body摘要>
+	if got := req.Header.Get("X-Acs-Content-Sha256"); got != "037c9214eef74cc3887f3a4f085b4e17d76280dafd273b0ee160c09c4ba1cfd4" {
+		t.Errorf("content hash = %s", got)
+	}
+	want := "ACS3-HMAC-SHA256 Credential=YourAccessKeyId,SignedHeaders=content-type;host;x-acs-action;x-acs-content-sha256;x-acs-date;x-acs-signature-nonce,Signature=4577913ab9a2f3c1f2c5a7b8346ff16fe68b54ac9bb21ce955e2eb6865bf0c40"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestSignerInPipeline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Body-Hash") + "|" + r.Header.Get("X-Signed-Url")))
+	}))
+	defer srv.Close()
+
+	var progressed bool
+	signer := SignerFunc(func(req *http.Request, payloadHash string) error {
+		req.Header.Set("X-Body-Hash", payloadHash)
+		req.Header.Set("X-Signed-Url", req.Method+" "+req.URL.RequestURI())
+		return nil
+	})
+	c := NewCurlx(WithOptionSigner(signer))
+	body, err := c.Send(context.Background(),
+		SetParamsUrl(srv.URL+"/upload?x=1"),
+		SetParamsMethod(MethodPost),
+		SetParamsBody([]byte("hello")),
+		SetParamsContentType(ContentTypeText),
+		SetParamsProgress(func(p Progress) { progressed = true }),
+	)
+	want := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824|POST /upload?x=1"
+	if err != nil || string(body) != want {
+		t.Errorf("body = %q, err = %v", body, err)
+	}
+	if !progressed {
+		t.Error("progress not reported")
+	}
+
+	// 签名失败时不发送请求
+	errSign := errors.New("no credentials")
+	failing := SignerFunc(func(req *http.Request, payloadHash string) error { return errSign })
+	_, err = c.Send(context.Background(), SetParamsUrl(srv.URL), SetParamsMethod(MethodGet), SetParamsSigner(failing))
+	if !errors.Is(err, errSign) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestSignerWithAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Signed-Url") + "|" + r.URL.RequestURI() + "|" + r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	// 认证先于签名，URL中的API Key参与签名
+	signer := SignerFunc(func(req *http.Request, payloadHash string) error {
+		req.Header.Set("X-Signed-Url", req.URL.RequestURI())
+		return nil
+	})
+	c := NewCurlx(WithOptionSigner(signer), WithOptionAuth(APIKeyAuth("api_key", "k1", APIKeyInQuery)))
+	body, err := c.Send(context.Background(), SetParamsUrl(srv.URL+"/items?b=2"), SetParamsMethod(MethodGet))
+	if want := "/items?api_key=k1&b=2|/items?api_key=k1&b=2|"; err != nil || string(body) != want {
+		t.Errorf("body = %q, want %q, err = %v", body, want, err)
+	}
+
+	// 签名不能覆盖认证的请求头
+	hmacSigner := &HMACSigner{AccessKeyID: "id", SecretKey: "secret"}
+	_, err = c.Send(context.Background(), SetParamsUrl(srv.URL), SetParamsMethod(MethodGet),
+		SetParamsAuth(BearerAuth("token")), SetParamsSigner(hmacSigner))
+	if !errors.Is(err, ErrSignConflict) {
+		t.Errorf("err = %v, want ErrSignConflict", err)
+	}
+
+	// 签名写入Authorization，认证使用其他请求头
+	body, err = c.Send(context.Background(), SetParamsUrl(srv.URL), SetParamsMethod(MethodGet),
+		SetParamsAuth(APIKeyAuth("X-Api-Key", "k2", APIKeyInHeader)), SetParamsSigner(hmacSigner))
+	if err != nil || !strings.Contains(string(body), "HMAC-SHA256 Credential=id,SignedHeaders=host,") {
+		t.Errorf("body = %q, err = %v", body, err)
+	}
+}
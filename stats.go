@@ -0,0 +1,194 @@
+package curlx
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// HostStats 单个主机的连接统计
+type HostStats struct {
+	Open   int64 // 已建立且未关闭的连接数
+	Active int64 // 正在被请求使用的连接数
+	Idle   int64 // 连接池中空闲的连接数
+}
+
+// Stats 连接池及传输层统计
+type Stats struct {
+	InFlight    int64 // 进行中的请求数(响应Body未关闭)
+	NewConns    int64 // 新建连接次数
+	ReusedConns int64 // 复用连接次数
+	DialErrors  int64 // 拨号失败次数
+	TLSErrors   int64 // TLS握手失败次数
+	Hosts       map[string]HostStats
+}
+
+type statsHostKey struct{}
+
+type hostCounter struct {
+	open   int64
+	active int64
+}
+
+type connStats struct {
+	inFlight    atomic.Int64
+	newConns    atomic.Int64
+	reusedConns atomic.Int64
+	dialErrors  atomic.Int64
+	tlsErrors   atomic.Int64
+
+	mu    sync.Mutex
+	hosts map[string]*hostCounter
+}
+
+func newConnStats() *connStats {
+	return &connStats{
+		hosts: map[string]*hostCounter{},
+	}
+}
+
+/**
+ * 获取连接池统计信息
+ */
+func (c *Curlx) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+func (s *connStats) snapshot() Stats {
+	st := Stats{
+		InFlight:    s.inFlight.Load(),
+		NewConns:    s.newConns.Load(),
+		ReusedConns: s.reusedConns.Load(),
+		DialErrors:  s.dialErrors.Load(),
+		TLSErrors:   s.tlsErrors.Load(),
+		Hosts:       map[string]HostStats{},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for host, hc := range s.hosts {
+		hs := HostStats{
+			Open:   hc.open,
+			Active: hc.active,
+		}
+		if idle := hc.open - hc.active; idle > 0 {
+			hs.Idle = idle
+		}
+		st.Hosts[host] = hs
+	}
+	return st
+}
+
+func (s *connStats) addHost(host string, open, active int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hc, ok := s.hosts[host]
+	if !ok {
+		hc = &hostCounter{}
+		s.hosts[host] = hc
+	}
+	hc.open += open
+	hc.active += active
+	if hc.open <= 0 && hc.active <= 0 {
+		delete(s.hosts, host)
+	}
+}
+
+/**
+ * 开始统计一次请求
+ * 返回带有httptrace的ctx，以及请求结束(响应Body关闭)时调用的函数
+ */
+func (s *connStats) begin(ctx context.Context, host string) (context.Context, func()) {
+	s.inFlight.Add(1)
+
+	var (
+		mu      sync.Mutex
+		holding bool
+	)
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				s.reusedConns.Add(1)
+			} else {
+				s.newConns.Add(1)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			// 重定向时上一跳的连接已释放，仍只占用一个连接
+			if !holding {
+				holding = true
+				s.addHost(host, 0, 1)
+			}
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err != nil {
+				s.tlsErrors.Add(1)
+			}
+		},
+	}
+
+	ctx = context.WithValue(ctx, statsHostKey{}, host)
+	ctx = httptrace.WithClientTrace(ctx, trace)
+
+	var once sync.Once
+	done := func() {
+		once.Do(func() {
+			s.inFlight.Add(-1)
+			mu.Lock()
+			defer mu.Unlock()
+			if holding {
+				holding = false
+				s.addHost(host, 0, -1)
+			}
+		})
+	}
+	return ctx, done
+}
+
+type dialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+/**
+ * 包装拨号函数，统计连接的建立与关闭
+ */
+func (s *connStats) wrapDialContext(dial dialContextFunc) dialContextFunc {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		// 只统计TCP连接，忽略DNS等UDP请求
+		if !strings.HasPrefix(network, "tcp") {
+			return conn, err
+		}
+		if err != nil {
+			s.dialErrors.Add(1)
+			return nil, err
+		}
+
+		host, _ := ctx.Value(statsHostKey{}).(string)
+		if host == "" {
+			host = addr
+		}
+		s.addHost(host, 1, 0)
+		return &statsConn{
+			Conn:    conn,
+			onClose: func() { s.addHost(host, -1, 0) },
+		}, nil
+	}
+}
+
+type statsConn struct {
+	net.Conn
+	once    sync.Once
+	onClose func()
+}
+
+func (c *statsConn) Close() error {
+	c.once.Do(c.onClose)
+	return c.Conn.Close()
+}
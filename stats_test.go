@@ -0,0 +1,65 @@
+package curlx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewCurlx()
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get(context.Background(), srv.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	u, _ := url.Parse(srv.URL)
+	stats := c.Stats()
+	if stats.InFlight != 0 {
+		t.Fatalf("InFlight = %d, want 0", stats.InFlight)
+	}
+	if stats.NewConns != 1 || stats.ReusedConns != 2 {
+		t.Fatalf("NewConns = %d ReusedConns = %d, want 1 and 2", stats.NewConns, stats.ReusedConns)
+	}
+	hs := stats.Hosts[u.Host]
+	if hs.Open != 1 || hs.Active != 0 || hs.Idle != 1 {
+		t.Fatalf("host stats = %+v", hs)
+	}
+
+	// 请求进行中时连接处于活跃状态
+	resp := c.SendWithResponse(context.Background(), SetParamsUrl(srv.URL), SetParamsMethod(MethodGet))
+	if resp.GetError() != nil {
+		t.Fatal(resp.GetError())
+	}
+	stats = c.Stats()
+	if stats.InFlight != 1 || stats.Hosts[u.Host].Active != 1 {
+		t.Fatalf("stats while in flight = %+v", stats)
+	}
+	resp.Close()
+
+	if stats = c.Stats(); stats.InFlight != 0 || stats.Hosts[u.Host].Active != 0 {
+		t.Fatalf("stats after close = %+v", stats)
+	}
+}
+
+func TestStatsDialError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	addr := srv.URL
+	srv.Close()
+
+	c := NewCurlx()
+	if _, err := c.Get(context.Background(), addr); err == nil {
+		t.Fatal("expected dial error")
+	}
+	if stats := c.Stats(); stats.DialErrors != 1 || stats.InFlight != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
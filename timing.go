@@ -0,0 +1,125 @@
+package curlx
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing 请求各阶段耗时，类似 curl -w 的输出
+type Timing struct {
+	DNSLookup       time.Duration // DNS解析耗时
+	TCPConnect      time.Duration // TCP连接耗时
+	TLSHandshake    time.Duration // TLS握手耗时
+	FirstByte       time.Duration // 从发起请求到收到响应首字节(TTFB)
+	ContentTransfer time.Duration // 从收到首字节到响应Body读取完成
+	Total           time.Duration // 总耗时
+	ConnReused      bool          // 是否复用连接
+	RemoteAddr      string        // 远端地址
+}
+
+type requestTiming struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	end          time.Time
+
+	reused     bool
+	remoteAddr string
+}
+
+func newRequestTiming() *requestTiming {
+	return &requestTiming{
+		start: time.Now(),
+	}
+}
+
+func (t *requestTiming) set(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn()
+}
+
+/**
+ * 在ctx中挂载httptrace，记录各阶段时间点
+ * 重定向时记录的是最后一跳的连接信息
+ */
+func (t *requestTiming) withTrace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.set(func() { t.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.set(func() { t.dnsDone = time.Now() })
+		},
+		ConnectStart: func(network, addr string) {
+			t.set(func() { t.connectStart = time.Now() })
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.set(func() { t.connectDone = time.Now() })
+		},
+		TLSHandshakeStart: func() {
+			t.set(func() { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.set(func() { t.tlsDone = time.Now() })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.set(func() {
+				t.reused = info.Reused
+				if info.Conn != nil {
+					t.remoteAddr = info.Conn.RemoteAddr().String()
+				}
+			})
+		},
+		GotFirstResponseByte: func() {
+			t.set(func() { t.firstByte = time.Now() })
+		},
+	})
+}
+
+// finish 请求结束(Body读取完成/关闭或请求失败)，只记录第一次
+func (t *requestTiming) finish() {
+	t.set(func() {
+		if t.end.IsZero() {
+			t.end = time.Now()
+		}
+	})
+}
+
+func (t *requestTiming) snapshot() Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tm := Timing{
+		DNSLookup:    since(t.dnsStart, t.dnsDone),
+		TCPConnect:   since(t.connectStart, t.connectDone),
+		TLSHandshake: since(t.tlsStart, t.tlsDone),
+		FirstByte:    since(t.start, t.firstByte),
+		ConnReused:   t.reused,
+		RemoteAddr:   t.remoteAddr,
+	}
+	if !t.end.IsZero() {
+		tm.ContentTransfer = since(t.firstByte, t.end)
+		tm.Total = since(t.start, t.end)
+	} else {
+		tm.Total = tm.FirstByte
+	}
+	return tm
+}
+
+func since(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -0,0 +1,42 @@
+package curlx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTiming(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewCurlx()
+	for i := 0; i < 2; i++ {
+		resp := c.SendWithResponse(context.Background(), SetParamsUrl(srv.URL), SetParamsMethod(MethodGet))
+		if _, err := resp.GetBody(); err != nil {
+			t.Fatal(err)
+		}
+
+		timing := resp.GetTiming()
+		if timing.FirstByte < 20*time.Millisecond {
+			t.Fatalf("FirstByte = %v, want >= 20ms", timing.FirstByte)
+		}
+		if timing.Total < timing.FirstByte {
+			t.Fatalf("Total = %v less than FirstByte = %v", timing.Total, timing.FirstByte)
+		}
+		if timing.RemoteAddr != srv.Listener.Addr().String() {
+			t.Fatalf("RemoteAddr = %q", timing.RemoteAddr)
+		}
+		if reused := i > 0; timing.ConnReused != reused {
+			t.Fatalf("request %d ConnReused = %v", i, timing.ConnReused)
+		}
+		if i == 0 && timing.TCPConnect == 0 {
+			t.Fatal("TCPConnect should be recorded for a new connection")
+		}
+	}
+}
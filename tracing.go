@@ -0,0 +1,304 @@
+package curlx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 链路追踪属性名，与OpenTelemetry语义约定一致
+const (
+	AttrHTTPMethod     = "http.request.method"
+	AttrURLFull        = "url.full"
+	AttrServerAddress  = "server.address"
+	AttrHTTPStatusCode = "http.response.status_code"
+	AttrErrorType      = "error.type"
+)
+
+type TraceID [16]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+type SpanID [8]byte
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// SpanContext 需要跨服务传递的链路信息
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string // W3C tracestate 原样传递
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+type spanContextKey struct{}
+
+/**
+ * 将链路信息放入ctx，发起请求时会自动注入到请求头
+ */
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+/**
+ * 从ctx获取链路信息
+ */
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// Tracer 链路追踪接口
+type Tracer interface {
+	// 开始一个Span，返回的ctx需要携带新Span的SpanContext
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span 单个请求的追踪区间
+type Span interface {
+	SpanContext() SpanContext
+	SetAttribute(key string, value any)
+	// 结束Span，err为请求失败原因
+	End(err error)
+}
+
+// Propagator 链路信息在请求头中的传递格式
+type Propagator interface {
+	Inject(ctx context.Context, h http.Header)
+	Extract(ctx context.Context, h http.Header) context.Context
+}
+
+/**
+ * 设置链路追踪
+ */
+func WithOptionTracer(t Tracer) Option {
+	return func(options *ClientOptions) {
+		options.Tracer = t
+	}
+}
+
+/**
+ * 设置链路信息的传递格式，默认W3C，传nil不注入请求头
+ */
+func WithOptionPropagator(p Propagator) Option {
+	return func(options *ClientOptions) {
+		options.Propagator = p
+	}
+}
+
+// TraceContextPropagator W3C Trace Context(traceparent/tracestate)
+type TraceContextPropagator struct{}
+
+func (TraceContextPropagator) Inject(ctx context.Context, h http.Header) {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	h.Set("traceparent", fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags))
+	if sc.TraceState != "" {
+		h.Set("tracestate", sc.TraceState)
+	} else {
+		h.Del("tracestate")
+	}
+}
+
+func (TraceContextPropagator) Extract(ctx context.Context, h http.Header) context.Context {
+	parts := strings.Split(strings.TrimSpace(h.Get("traceparent")), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return ctx
+	}
+	// 版本00必须恰好4段
+	if parts[0] == "00" && len(parts) != 4 {
+		return ctx
+	}
+	sc := SpanContext{}
+	if !decodeHex(parts[1], sc.TraceID[:]) || !decodeHex(parts[2], sc.SpanID[:]) || !sc.IsValid() {
+		return ctx
+	}
+	flags := []byte{0}
+	if !decodeHex(parts[3], flags) {
+		return ctx
+	}
+	sc.Sampled = flags[0]&0x01 == 0x01
+	sc.TraceState = h.Get("tracestate")
+	return ContextWithSpanContext(ctx, sc)
+}
+
+// B3Propagator Zipkin B3格式，默认多请求头(X-B3-*)
+type B3Propagator struct {
+	SingleHeader bool // 使用单个 b3 请求头
+}
+
+func (p B3Propagator) Inject(ctx context.Context, h http.Header) {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return
+	}
+	sampled := "0"
+	if sc.Sampled {
+		sampled = "1"
+	}
+	if p.SingleHeader {
+		h.Set("b3", fmt.Sprintf("%s-%s-%s", sc.TraceID, sc.SpanID, sampled))
+		return
+	}
+	h.Set("X-B3-TraceId", sc.TraceID.String())
+	h.Set("X-B3-SpanId", sc.SpanID.String())
+	h.Set("X-B3-Sampled", sampled)
+}
+
+func (p B3Propagator) Extract(ctx context.Context, h http.Header) context.Context {
+	var traceID, spanID, sampled string
+	if single := h.Get("b3"); single != "" {
+		parts := strings.Split(single, "-")
+		if len(parts) < 2 {
+			return ctx
+		}
+		traceID, spanID = parts[0], parts[1]
+		if len(parts) > 2 {
+			sampled = parts[2]
+		}
+	} else {
+		traceID, spanID, sampled = h.Get("X-B3-TraceId"), h.Get("X-B3-SpanId"), h.Get("X-B3-Sampled")
+	}
+
+	// 64位TraceId左侧补零
+	if len(traceID) == 16 {
+		traceID = strings.Repeat("0", 16) + traceID
+	}
+	sc := SpanContext{Sampled: sampled == "1" || sampled == "d"}
+	if !decodeHex(traceID, sc.TraceID[:]) || !decodeHex(spanID, sc.SpanID[:]) || !sc.IsValid() {
+		return ctx
+	}
+	return ContextWithSpanContext(ctx, sc)
+}
+
+func decodeHex(s string, dst []byte) bool {
+	if len(s) != hex.EncodedLen(len(dst)) {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+func newTraceID() TraceID {
+	t := TraceID{}
+	rand.Read(t[:])
+	return t
+}
+
+func newSpanID() SpanID {
+	s := SpanID{}
+	rand.Read(s[:])
+	return s
+}
+
+// RecordedSpan 内存记录的Span
+type RecordedSpan struct {
+	Name        string
+	SpanContext SpanContext
+	Parent      SpanID // 无父Span时为空
+	Attributes  map[string]any
+	Err         error
+	StartTime   time.Time
+	EndTime     time.Time
+}
+
+// SpanRecorder 内存中的Tracer，不依赖采集服务，主要用于测试
+type SpanRecorder struct {
+	mu    sync.Mutex
+	spans []RecordedSpan
+}
+
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+func (r *SpanRecorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recorderSpan{
+		recorder: r,
+		data: RecordedSpan{
+			Name:       name,
+			Attributes: map[string]any{},
+			StartTime:  time.Now(),
+		},
+	}
+	if parent, ok := SpanContextFromContext(ctx); ok {
+		span.data.Parent = parent.SpanID
+		span.data.SpanContext = parent
+	} else {
+		span.data.SpanContext = SpanContext{TraceID: newTraceID(), Sampled: true}
+	}
+	span.data.SpanContext.SpanID = newSpanID()
+
+	return ContextWithSpanContext(ctx, span.data.SpanContext), span
+}
+
+/**
+ * 获取已结束的Span
+ */
+func (r *SpanRecorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedSpan{}, r.spans...)
+}
+
+/**
+ * 清空已记录的Span
+ */
+func (r *SpanRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
+
+type recorderSpan struct {
+	recorder *SpanRecorder
+	mu       sync.Mutex
+	data     RecordedSpan
+	ended    bool
+}
+
+func (s *recorderSpan) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+func (s *recorderSpan) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes[key] = value
+}
+
+func (s *recorderSpan) End(err error) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.Err = err
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.recorder.spans = append(s.recorder.spans, data)
+}
@@ -0,0 +1,113 @@
+package curlx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTracingRecorder(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	parent := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true, TraceState: "vendor=1"}
+	ctx := ContextWithSpanContext(context.Background(), parent)
+
+	recorder := NewSpanRecorder()
+	c := NewCurlx(WithOptionTracer(recorder))
+	c.Get(ctx, srv.URL+"/path")
+
+	spans := recorder.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.SpanContext.TraceID != parent.TraceID || span.Parent != parent.SpanID {
+		t.Fatalf("span is not a child of the parent: %+v", span)
+	}
+	if span.Attributes[AttrHTTPMethod] != "GET" || span.Attributes[AttrURLFull] != srv.URL+"/path" {
+		t.Fatalf("attributes = %+v", span.Attributes)
+	}
+	if span.Attributes[AttrHTTPStatusCode] != 500 || span.Attributes[AttrErrorType] != "500" {
+		t.Fatalf("attributes = %+v", span.Attributes)
+	}
+
+	// 服务端收到的是新Span的信息
+	got, ok := SpanContextFromContext(TraceContextPropagator{}.Extract(context.Background(), header))
+	if !ok {
+		t.Fatalf("traceparent not injected: %v", header)
+	}
+	if got.TraceID != parent.TraceID || got.SpanID != span.SpanContext.SpanID || !got.Sampled || got.TraceState != "vendor=1" {
+		t.Fatalf("extracted = %+v", got)
+	}
+}
+
+func TestTracingB3Propagator(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	parent := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	ctx := ContextWithSpanContext(context.Background(), parent)
+
+	// 未设置Tracer时原样传递ctx中的链路信息
+	c := NewCurlx(WithOptionPropagator(B3Propagator{}))
+	if _, err := c.Get(ctx, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("traceparent") != "" {
+		t.Fatal("traceparent should not be injected by B3 propagator")
+	}
+	got, ok := SpanContextFromContext(B3Propagator{}.Extract(context.Background(), header))
+	if !ok || got != parent {
+		t.Fatalf("extracted = %+v, want %+v", got, parent)
+	}
+}
+
+func TestTraceContextExtract(t *testing.T) {
+	cases := map[string]bool{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       true,
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01":       false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7":          false,
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       false,
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": true,
+	}
+	for traceparent, valid := range cases {
+		h := http.Header{}
+		h.Set("traceparent", traceparent)
+		_, ok := SpanContextFromContext(TraceContextPropagator{}.Extract(context.Background(), h))
+		if ok != valid {
+			t.Errorf("%s: valid = %v, want %v", traceparent, ok, valid)
+		}
+	}
+}
+
+func TestTracingRedactsURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	recorder := NewSpanRecorder()
+	c := NewCurlx(WithOptionTracer(recorder))
+	c.Send(context.Background(),
+		SetParamsUrl(srv.URL+"/path?access_token=token-secret&sig=query-secret&page=1"),
+		SetParamsMethod(MethodGet),
+		SetParamsAuth(APIKeyAuth("sig", "query-secret", APIKeyInQuery)),
+	)
+
+	spans := recorder.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	full, _ := spans[0].Attributes[AttrURLFull].(string)
+	if strings.Contains(full, "secret") || !strings.Contains(full, "page=1") {
+		t.Fatalf("%s = %q", AttrURLFull, full)
+	}
+}
@@ -0,0 +1,150 @@
+package curlx
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ErrTransportNotConfigurable 自定义传输不是 *http.Transport，无法设置代理或拨号
+var ErrTransportNotConfigurable = errors.New("curlx: transport is not *http.Transport")
+
+/**
+ * 使用自定义传输作为底层传输，中间件仍包装在其外层
+ * 连接池、TLS等选项不会作用于自定义传输，需要时使用 NewTransport 创建后再包装
+ * 自定义传输为 *http.Transport 时，代理相关方法会修改该传输
+ */
+func WithOptionTransport(rt http.RoundTripper) Option {
+	return func(options *ClientOptions) {
+		options.Transport = rt
+	}
+}
+
+/**
+ * 使用自定义 http.Client
+ * 保留其 Transport、Jar、CheckRedirect 和 Timeout，Timeout为0时使用 WithOptionTimeOut 的值
+ * Transport为nil时复制 http.DefaultTransport，连接池、TLS选项和连接统计作用于该副本
+ * 同时设置 WithOptionTransport 时以 WithOptionTransport 为准
+ */
+func WithOptionHTTPClient(client *http.Client) Option {
+	return func(options *ClientOptions) {
+		options.HTTPClient = client
+	}
+}
+
+/**
+ * 不跟随重定向，直接返回3xx响应
+ * 优先于 WithOptionHTTPClient 中的 CheckRedirect
+ */
+func WithOptionNoRedirect() Option {
+	return func(options *ClientOptions) {
+		options.NoRedirect = true
+	}
+}
+
+/**
+ * 按选项创建传输，可包装后通过 WithOptionTransport 使用
+ * 只使用连接池和TLS相关选项
+ */
+func NewTransport(opts ...Option) *http.Transport {
+	o := defaultOptions()
+	for _, apply := range opts {
+		apply(&o)
+	}
+	return newTransport(o)
+}
+
+func newTransport(o ClientOptions) *http.Transport {
+	transport := &http.Transport{
+		DisableKeepAlives:     false, // 启用keep-alive连接复用
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+	}
+	configureTransport(transport, o)
+	return transport
+}
+
+/**
+ * 设置传输的连接池和TLS选项
+ */
+func configureTransport(transport *http.Transport, o ClientOptions) {
+	transport.MaxIdleConns = o.MaxIdleConns
+	transport.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = o.MaxConnsPerHost
+	transport.IdleConnTimeout = o.IdleConnTimeout
+
+	if o.TLSConfig != nil {
+		transport.TLSClientConfig = o.TLSConfig.Clone()
+	}
+	if o.InsecureSkipVerify {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+}
+
+/**
+ * 确定底层传输，返回的 *http.Transport 用于设置代理，不可设置时为nil
+ */
+func baseTransport(o ClientOptions, stats *connStats) (http.RoundTripper, *http.Transport) {
+	var rt http.RoundTripper
+	switch {
+	case o.Transport != nil:
+		rt = o.Transport
+	case o.HTTPClient != nil && o.HTTPClient.Transport != nil:
+		rt = o.HTTPClient.Transport
+	default:
+		// 自定义 http.Client 未设置Transport时与其行为一致，基于 http.DefaultTransport(使用环境变量代理)
+		// 不修改全局的 http.DefaultTransport
+		var transport *http.Transport
+		if o.HTTPClient != nil {
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+			configureTransport(transport, o)
+		} else {
+			transport = newTransport(o)
+		}
+		// 包装拨号函数用于连接统计
+		transport.DialContext = stats.wrapDialContext(transport.DialContext)
+		return transport, transport
+	}
+	transport, _ := rt.(*http.Transport)
+	return rt, transport
+}
+
+/**
+ * 每次请求使用的 http.Client
+ */
+func (c *Curlx) httpClient() *http.Client {
+	client := &http.Client{
+		Timeout: c.opts.TimeOut, // 整个请求的超时时间 设置该条连接的超时
+	}
+	if hc := c.opts.HTTPClient; hc != nil {
+		*client = *hc
+		if client.Timeout == 0 {
+			client.Timeout = c.opts.TimeOut
+		}
+	}
+	client.Transport = c.roundTripper
+
+	if c.opts.NoRedirect {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	} else if client.CheckRedirect == nil {
+		// 在http.Client中添加CheckRedirect函数 实现重定向控制
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 { // 限制重定向次数
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+	}
+	return client
+}
@@ -0,0 +1,111 @@
+package curlx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWithOptionTransport(t *testing.T) {
+	var order []string
+	base := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		order = append(order, "base")
+		return &http.Response{StatusCode: 200, Body: http.NoBody, Request: r}, nil
+	})
+	mw := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			order = append(order, "middleware")
+			return next.RoundTrip(r)
+		})
+	}
+
+	c := NewCurlx(WithOptionTransport(base), WithOptionMiddleware(mw))
+	if _, err := c.Send(context.Background(), SetParamsUrl("http://example.invalid/"), SetParamsMethod(MethodGet)); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 2 || order[0] != "middleware" || order[1] != "base" {
+		t.Errorf("order = %v", order)
+	}
+
+	if err := c.WithProxyHttp("http://127.0.0.1:8080"); !errors.Is(err, ErrTransportNotConfigurable) {
+		t.Errorf("WithProxyHttp err = %v", err)
+	}
+}
+
+func TestWithOptionTransportComposable(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Mesh")))
+	}))
+	defer srv.Close()
+
+	// 选项显式作用于传输，再由外层包装
+	transport := NewTransport(WithOptionTLSInsecureSkipVerify())
+	mesh := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		r.Header.Set("X-Mesh", "on")
+		return transport.RoundTrip(r)
+	})
+
+	c := NewCurlx(WithOptionTransport(mesh))
+	body, err := c.Send(context.Background(), SetParamsUrl(srv.URL), SetParamsMethod(MethodGet))
+	if err != nil || string(body) != "on" {
+		t.Fatalf("body = %q, err = %v", body, err)
+	}
+}
+
+func TestWithOptionHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/target", http.StatusFound)
+			return
+		}
+		w.Write([]byte("target"))
+	}))
+	defer srv.Close()
+
+	hc := &http.Client{
+		Timeout: time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	c := NewCurlx(WithOptionHTTPClient(hc))
+	resp := c.SendWithResponse(context.Background(), SetParamsUrl(srv.URL+"/redirect"), SetParamsMethod(MethodGet))
+	defer resp.Close()
+	if resp.GetStatusCode() != http.StatusFound {
+		t.Errorf("status = %d, want 302", resp.GetStatusCode())
+	}
+	if hc.Transport != nil {
+		t.Error("custom client should not be modified")
+	}
+	if err := c.WithProxyHttp("http://127.0.0.1:8080"); err != nil {
+		t.Errorf("WithProxyHttp err = %v", err)
+	}
+	if c.transport == http.DefaultTransport.(*http.Transport) {
+		t.Error("default transport should not be shared")
+	}
+}
+
+// 自定义 http.Client 未设置Transport时，TLS、连接池选项和连接统计仍然生效
+func TestWithOptionHTTPClientOptions(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewCurlx(WithOptionHTTPClient(&http.Client{}), WithOptionTLSInsecureSkipVerify(), WithMaxConnsPerHost(5))
+	body, err := c.Get(context.Background(), srv.URL)
+	if err != nil || string(body) != "ok" {
+		t.Fatalf("body = %q, err = %v", body, err)
+	}
+	if c.transport.MaxConnsPerHost != 5 {
+		t.Errorf("MaxConnsPerHost = %d, want 5", c.transport.MaxConnsPerHost)
+	}
+	u, _ := url.Parse(srv.URL)
+	if stats := c.Stats(); stats.NewConns != 1 || stats.Hosts[u.Host].Open != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
@@ -0,0 +1,186 @@
+package curlx
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// ErrSignatureInvalid 响应签名校验失败
+var ErrSignatureInvalid = errors.New("curlx: response signature invalid")
+
+// ResponseVerifier 响应签名校验
+// 读取响应Body后调用，Send 和 GetBody 在校验失败时返回错误，不返回Body
+type ResponseVerifier interface {
+	Verify(resp *http.Response, body []byte) error
+}
+
+// ResponseVerifierFunc 函数形式的 ResponseVerifier
+type ResponseVerifierFunc func(resp *http.Response, body []byte) error
+
+func (f ResponseVerifierFunc) Verify(resp *http.Response, body []byte) error {
+	return f(resp, body)
+}
+
+/**
+ * 设置客户端默认的响应签名校验
+ * GetBody、Send 和 SendStream 会校验签名，SendStream 需要先读取完整Body
+ * Download 按Range分段和续传，不校验签名，下载文件请使用 WithDownloadChecksum 校验
+ */
+func WithOptionVerifier(verifier ResponseVerifier) Option {
+	return func(options *ClientOptions) {
+		options.Verifier = verifier
+	}
+}
+
+/**
+ * 设置请求的响应签名校验，优先于客户端的校验
+ */
+func SetParamsVerifier(verifier ResponseVerifier) Param {
+	return func(param *ClientParams) {
+		param.Verifier = verifier
+	}
+}
+
+// HMACVerifier 校验响应头中的HMAC签名，适用于Webhook风格的Body签名
+// 签名支持十六进制和Base64编码
+type HMACVerifier struct {
+	Secret  []byte
+	Header  string                                        // 签名所在的响应头
+	Prefix  string                                        // 签名值的前缀，如 "sha256="
+	Hash    func() hash.Hash                              // 默认 sha256.New
+	Message func(resp *http.Response, body []byte) []byte // 待签名内容，默认为Body
+}
+
+func (v *HMACVerifier) Verify(resp *http.Response, body []byte) error {
+	signature, err := signatureHeader(resp, v.Header, v.Prefix)
+	if err != nil {
+		return err
+	}
+	h := v.Hash
+	if h == nil {
+		h = sha256.New
+	}
+	mac := hmac.New(h, v.Secret)
+	mac.Write(verifyMessage(v.Message, resp, body))
+	expected := mac.Sum(nil)
+
+	got, err := hex.DecodeString(signature)
+	if err != nil || len(got) != len(expected) {
+		if got, err = base64.StdEncoding.DecodeString(signature); err != nil {
+			return fmt.Errorf("%w: malformed %s", ErrSignatureInvalid, v.Header)
+		}
+	}
+	if !hmac.Equal(got, expected) {
+		return fmt.Errorf("%w: %s mismatch", ErrSignatureInvalid, v.Header)
+	}
+	return nil
+}
+
+// PublicKeyVerifier 使用RSA(PKCS#1 v1.5)或ECDSA(ASN.1)公钥校验Base64编码的签名
+type PublicKeyVerifier struct {
+	Key     crypto.PublicKey                              // *rsa.PublicKey 或 *ecdsa.PublicKey
+	Header  string                                        // 签名所在的响应头
+	Hash    crypto.Hash                                   // 默认 crypto.SHA256
+	Message func(resp *http.Response, body []byte) []byte // 待签名内容，默认为Body
+}
+
+func (v *PublicKeyVerifier) Verify(resp *http.Response, body []byte) error {
+	signature, err := signatureHeader(resp, v.Header, "")
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed %s", ErrSignatureInvalid, v.Header)
+	}
+	hashType := v.Hash
+	if hashType == 0 {
+		hashType = crypto.SHA256
+	}
+	if !hashType.Available() {
+		return fmt.Errorf("curlx: hash %v not available", hashType)
+	}
+	h := hashType.New()
+	h.Write(verifyMessage(v.Message, resp, body))
+	digest := h.Sum(nil)
+
+	switch key := v.Key.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(key, hashType, digest, sig)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, sig) {
+			err = errors.New("ecdsa verification failed")
+		}
+	default:
+		return fmt.Errorf("curlx: unsupported public key type %T", v.Key)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignatureInvalid, err)
+	}
+	return nil
+}
+
+/**
+ * 微信支付的待签名内容: 时间戳\n随机串\nBody\n
+ * 与 PublicKeyVerifier{Header: "Wechatpay-Signature"} 配合使用
+ */
+func WechatPayMessage(resp *http.Response, body []byte) []byte {
+	return []byte(resp.Header.Get("Wechatpay-Timestamp") + "\n" +
+		resp.Header.Get("Wechatpay-Nonce") + "\n" +
+		string(body) + "\n")
+}
+
+/**
+ * 解析PEM格式的公钥或证书
+ */
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("curlx: no PEM data found")
+	}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+}
+
+func signatureHeader(resp *http.Response, header, prefix string) (string, error) {
+	value := strings.TrimSpace(resp.Header.Get(header))
+	if value == "" {
+		return "", fmt.Errorf("%w: missing %s", ErrSignatureInvalid, header)
+	}
+	if prefix != "" {
+		if !strings.HasPrefix(value, prefix) {
+			return "", fmt.Errorf("%w: malformed %s", ErrSignatureInvalid, header)
+		}
+		value = value[len(prefix):]
+	}
+	return value, nil
+}
+
+func verifyMessage(message func(*http.Response, []byte) []byte, resp *http.Response, body []byte) []byte {
+	if message == nil {
+		return body
+	}
+	return message(resp, body)
+}
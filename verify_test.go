@@ -0,0 +1,123 @@
+package curlx
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHMACVerifier(t *testing.T) {
+	secret := []byte("webhook-secret")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := []byte(`{"event":"paid"}`)
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		sig := hex.EncodeToString(mac.Sum(nil))
+		if r.URL.Query().Get("tamper") != "" {
+			body = []byte(`{"event":"refunded"}`)
+		}
+		w.Header().Set("X-Signature", "sha256="+sig)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	c := NewCurlx(WithOptionVerifier(&HMACVerifier{Secret: secret, Header: "X-Signature", Prefix: "sha256="}))
+	body, err := c.Send(context.Background(), SetParamsUrl(srv.URL), SetParamsMethod(MethodGet))
+	if err != nil || string(body) != `{"event":"paid"}` {
+		t.Fatalf("body = %q, err = %v", body, err)
+	}
+
+	body, err = c.Send(context.Background(), SetParamsUrl(srv.URL+"?tamper=1"), SetParamsMethod(MethodGet))
+	if !errors.Is(err, ErrSignatureInvalid) || body != nil {
+		t.Errorf("body = %q, err = %v", body, err)
+	}
+
+	// GetBody 同样校验
+	resp := c.SendWithResponse(context.Background(), SetParamsUrl(srv.URL+"?tamper=1"), SetParamsMethod(MethodGet))
+	if _, err := resp.GetBody(); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("GetBody err = %v", err)
+	}
+	if _, err := resp.GetBody(); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("second GetBody err = %v", err)
+	}
+
+	// SendStream 校验通过才返回内容
+	for query, want := range map[string]int{"": 1, "?tamper=1": 0} {
+		lines, err := c.SendStream(context.Background(), SetParamsUrl(srv.URL+query), SetParamsMethod(MethodGet))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := 0
+		for range lines {
+			got++
+		}
+		if got != want {
+			t.Errorf("SendStream%s lines = %d, want %d", query, got, want)
+		}
+	}
+}
+
+func TestPublicKeyVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"code":"SUCCESS"}`)
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Wechatpay-Timestamp", "1700000000")
+	resp.Header.Set("Wechatpay-Nonce", "593BEC0C930BF1AFEB40B4A08C8FB242")
+	digest := sha256.Sum256(WechatPayMessage(resp, body))
+
+	rsaSig, _ := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	ecSig, _ := ecdsa.SignASN1(rand.Reader, ecKey, digest[:])
+
+	der, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	rsaPub, err := ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		key  crypto.PublicKey
+		sig  []byte
+		body []byte
+		ok   bool
+	}{
+		{"rsa", rsaPub, rsaSig, body, true},
+		{"ecdsa", &ecKey.PublicKey, ecSig, body, true},
+		{"rsa tampered", rsaPub, rsaSig, []byte(`{"code":"FAIL"}`), false},
+		{"ecdsa wrong key", rsaPub, ecSig, body, false},
+	}
+	for _, c := range cases {
+		resp.Header.Set("Wechatpay-Signature", base64.StdEncoding.EncodeToString(c.sig))
+		v := &PublicKeyVerifier{Key: c.key, Header: "Wechatpay-Signature", Message: WechatPayMessage}
+		err := v.Verify(resp, c.body)
+		if c.ok && err != nil || !c.ok && !errors.Is(err, ErrSignatureInvalid) {
+			t.Errorf("%s: err = %v", c.name, err)
+		}
+	}
+
+	resp.Header.Del("Wechatpay-Signature")
+	v := &PublicKeyVerifier{Key: rsaPub, Header: "Wechatpay-Signature"}
+	if err := v.Verify(resp, body); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("missing header err = %v", err)
+	}
+}